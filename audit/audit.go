@@ -0,0 +1,180 @@
+// Package audit keeps a worker's record of the searches it served: one
+// JSON object per line in a local file, rotated by size like the logs the
+// worker searches (audit.log, audit.log.1, ... with .1 the newest copy).
+package audit
+
+import (
+	grep "MP1/protoBuilds"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Log appends records to a file, rotating it once it reaches MaxSize.
+type Log struct {
+	Path    string
+	MaxSize int64 // bytes; 0 for no rotation
+	Keep    int   // rotated copies kept besides Path
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// Open opens or creates the file at l.Path for appending.
+func (l *Log) Open() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.open()
+}
+
+func (l *Log) open() error {
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f, l.size = f, fi.Size()
+	return nil
+}
+
+// Write appends r as one line, rotating the file first if the line would
+// take it past MaxSize.
+func (l *Log) Write(r *grep.AuditRecord) error {
+	b, err := protojson.Marshal(r)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return fmt.Errorf("audit log %s is not open", l.Path)
+	}
+	if l.MaxSize > 0 && l.size > 0 && l.size+int64(len(b)) > l.MaxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.f.Write(b)
+	l.size += int64(n)
+	return err
+}
+
+// rotate shifts Path.N to Path.N+1, dropping the oldest beyond Keep, moves
+// Path to Path.1 and starts a new Path.
+func (l *Log) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	l.f = nil
+	if l.Keep < 1 {
+		if err := os.Remove(l.Path); err != nil {
+			return err
+		}
+		return l.open()
+	}
+	os.Remove(rotated(l.Path, l.Keep))
+	for i := l.Keep - 1; i >= 1; i-- {
+		if err := os.Rename(rotated(l.Path, i), rotated(l.Path, i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(l.Path, rotated(l.Path, 1)); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// Close closes the file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+func rotated(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// Read calls fn for every record in the log, its rotated copies first,
+// oldest first. Lines that do not parse, such as one cut short by a crash,
+// are skipped. Records written while Read runs are not seen.
+func (l *Log) Read(fn func(*grep.AuditRecord) error) error {
+	files, sizes, err := l.snapshot()
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	if err != nil {
+		return err
+	}
+	for i, f := range files {
+		if err := readFile(io.NewSectionReader(f, 0, sizes[i]), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshot opens the log's files, oldest first, with rotation held off so
+// that none is missed or opened twice, and returns their sizes. The files
+// are read afterwards without the lock: an open file keeps its data when
+// it is renamed or removed, and reading only up to its size now keeps out
+// lines written since.
+func (l *Log) snapshot() (files []*os.File, sizes []int64, err error) {
+	paths := []string{l.Path}
+	for i := 1; i <= l.Keep; i++ {
+		paths = append([]string{rotated(l.Path, i)}, paths...)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return files, sizes, err
+		}
+		files = append(files, f)
+		fi, err := f.Stat()
+		if err != nil {
+			return files, sizes, err
+		}
+		sizes = append(sizes, fi.Size())
+	}
+	return files, sizes, nil
+}
+
+func readFile(r io.Reader, fn func(*grep.AuditRecord) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	opts := protojson.UnmarshalOptions{DiscardUnknown: true}
+	for sc.Scan() {
+		rec := &grep.AuditRecord{}
+		if opts.Unmarshal(sc.Bytes(), rec) != nil {
+			continue
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	grep "MP1/protoBuilds"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadDuringWrites(t *testing.T) {
+	l := &Log{Path: filepath.Join(t.TempDir(), "audit.log"), MaxSize: 200, Keep: 3}
+	if err := l.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	write := func(user string) {
+		if err := l.Write(&grep.AuditRecord{User: user, Host: "vm1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, u := range []string{"a", "b", "c", "d", "e", "f"} {
+		write(u)
+	}
+
+	// Writing, and so rotating, from inside fn must neither block nor
+	// change what this Read sees.
+	var got []string
+	err := l.Read(func(r *grep.AuditRecord) error {
+		got = append(got, r.User)
+		write("x" + r.User)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c", "d", "e", "f"}; !slices.Equal(got, want) {
+		t.Errorf("Read = %v, want %v", got, want)
+	}
+
+	got = got[:0]
+	if err := l.Read(func(r *grep.AuditRecord) error {
+		got = append(got, r.User)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Rotation keeps the newest records.
+	if want := []string{"xc", "xd", "xe", "xf"}; !slices.Equal(got[len(got)-4:], want) {
+		t.Errorf("second Read = %v, want it to end with %v", got, want)
+	}
+}
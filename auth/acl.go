@@ -0,0 +1,178 @@
+// Package auth identifies the callers of a worker and decides what they
+// may search. A caller is identified by a bearer token in the request
+// metadata or, on a mutual TLS connection, by the common name of its
+// client certificate. An ACL file maps each identity to the modes it may
+// use and the log files it may read.
+package auth
+
+import (
+	"MP1/properties"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Modes a user can be allowed. The first three are search modes; lines
+// also covers follow, LinesAround and ReadRange, which return file
+// contents. Cluster is for workers: replication and the failure detector.
+// Audit is for reading the audit log of the searches others ran.
+const (
+	ModeLines     = "lines"
+	ModeCount     = "count"
+	ModeAggregate = "aggregate"
+	ModeCluster   = "cluster"
+	ModeAudit     = "audit"
+)
+
+// defaultModes are a user's modes when the ACL does not list them.
+var defaultModes = []string{ModeLines, ModeCount, ModeAggregate}
+
+// User is an authenticated caller and what it may do. A nil *User, as
+// found when no ACL is configured, may do anything.
+type User struct {
+	Name  string
+	Globs []string // base names of the files it may search; empty for all
+	Modes map[string]bool
+}
+
+// Allows reports whether u may use mode.
+func (u *User) Allows(mode string) bool {
+	return u == nil || u.Modes[mode]
+}
+
+// CanRead reports whether u may search or read the log file at path.
+func (u *User) CanRead(path string) bool {
+	if u == nil || len(u.Globs) == 0 {
+		return true
+	}
+	base := filepath.Base(path)
+	for _, g := range u.Globs {
+		if ok, _ := filepath.Match(g, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the files in paths that u may read.
+func (u *User) Filter(paths []string) []string {
+	if u == nil || len(u.Globs) == 0 {
+		return paths
+	}
+	var out []string
+	for _, p := range paths {
+		if u.CanRead(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ACL is the set of users a worker accepts.
+type ACL struct {
+	users  map[string]*User
+	tokens map[string]*User // hex SHA-256 of the token -> user
+}
+
+// Load reads an ACL file in cluster.properties format:
+//
+//	user.NAME.token.sha256=hex SHA-256 of NAME's bearer token
+//	user.NAME.globs=comma-separated globs of the log files NAME may read (default all)
+//	user.NAME.modes=comma-separated lines, count, aggregate, cluster or audit (default lines,count,aggregate)
+//
+// A user without a token can only authenticate with a client certificate
+// whose common name is NAME.
+func Load(path string) (*ACL, error) {
+	p, err := properties.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &ACL{users: map[string]*User{}, tokens: map[string]*User{}}
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		rest, ok := strings.CutPrefix(k, "user.")
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown key %s", path, k)
+		}
+		name, field, ok := cutLast(rest)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s: bad key %s", path, k)
+		}
+		u := a.users[name]
+		if u == nil {
+			u = &User{Name: name}
+			a.users[name] = u
+		}
+		v := p[k]
+		switch field {
+		case "token.sha256":
+			sum, err := hex.DecodeString(v)
+			if err != nil || len(sum) != sha256.Size {
+				return nil, fmt.Errorf("%s: %s is not a hex SHA-256", path, k)
+			}
+			a.tokens[hex.EncodeToString(sum)] = u
+		case "globs":
+			for _, g := range splitList(v) {
+				if _, err := filepath.Match(g, ""); err != nil {
+					return nil, fmt.Errorf("%s: %s: bad glob %q", path, k, g)
+				}
+				u.Globs = append(u.Globs, g)
+			}
+		case "modes":
+			u.Modes = map[string]bool{}
+			for _, m := range splitList(v) {
+				switch m {
+				case ModeLines, ModeCount, ModeAggregate, ModeCluster, ModeAudit:
+					u.Modes[m] = true
+				default:
+					return nil, fmt.Errorf("%s: %s: unknown mode %q", path, k, m)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%s: unknown key %s", path, k)
+		}
+	}
+	for _, u := range a.users {
+		if u.Modes == nil {
+			u.Modes = map[string]bool{}
+			for _, m := range defaultModes {
+				u.Modes[m] = true
+			}
+		}
+	}
+	return a, nil
+}
+
+// cutLast splits "NAME.field" where field is one of the known fields, so
+// user names may contain dots, as host names do.
+func cutLast(s string) (name, field string, ok bool) {
+	for _, f := range []string{"token.sha256", "globs", "modes"} {
+		if n, ok := strings.CutSuffix(s, "."+f); ok {
+			return n, f, true
+		}
+	}
+	return "", "", false
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// byToken returns the user a bearer token belongs to.
+func (a *ACL) byToken(token string) *User {
+	sum := sha256.Sum256([]byte(token))
+	return a.tokens[hex.EncodeToString(sum[:])]
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeACL writes lines to an ACL file and returns its path.
+func writeACL(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "acl.properties")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoad(t *testing.T) {
+	a, err := Load(writeACL(t,
+		"user.alice.token.sha256="+tokenHash("alice-secret"),
+		"user.alice.globs=app.*.log, vm1.log",
+		"user.bob.token.sha256="+tokenHash("bob-secret"),
+		"user.bob.modes=count",
+		"user.vm1.example.com.modes=cluster",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := a.byToken("alice-secret")
+	if alice == nil || alice.Name != "alice" {
+		t.Fatalf("alice's token gives %+v", alice)
+	}
+	if !slices.Equal(alice.Globs, []string{"app.*.log", "vm1.log"}) {
+		t.Fatalf("alice's globs = %q", alice.Globs)
+	}
+	if !alice.Allows(ModeLines) || !alice.Allows(ModeCount) || !alice.Allows(ModeAggregate) || alice.Allows(ModeCluster) || alice.Allows(ModeAudit) {
+		t.Fatalf("alice's modes = %v, want the search modes", alice.Modes)
+	}
+	if bob := a.byToken("bob-secret"); bob == nil || bob.Allows(ModeLines) || !bob.Allows(ModeCount) {
+		t.Fatalf("bob = %+v, want count only", bob)
+	}
+	if a.byToken("carol-secret") != nil {
+		t.Fatal("an unknown token found a user")
+	}
+	// Names may contain dots, as host names do.
+	if u := a.users["vm1.example.com"]; u == nil || !u.Allows(ModeCluster) {
+		t.Fatalf("vm1.example.com = %+v, want a cluster user", u)
+	}
+}
+
+func TestLoadRejectsMalformed(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{"group.ops.modes=lines", "unknown key"},
+		{"user.alice.password=x", "bad key"},
+		{"user..modes=lines", "bad key"},
+		{"user.alice.token.sha256=abc", "not a hex SHA-256"},
+		{"user.alice.token.sha256=" + strings.Repeat("zz", sha256.Size), "not a hex SHA-256"},
+		{"user.alice.modes=lines,admin", `unknown mode "admin"`},
+		{"user.alice.globs=[a-", "bad glob"},
+	}
+	for _, tt := range tests {
+		_, err := Load(writeACL(t, tt.line))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%q) = %v, want an error containing %q", tt.line, err, tt.want)
+		}
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	files := []string{"/logs/app.1.log", "/logs/app.1.log.2.gz", "/logs/vm1.log", "/logs/secret.log"}
+	u := &User{Name: "alice", Globs: []string{"app.*.log", "vm1.log"}}
+	if got, want := u.Filter(files), []string{"/logs/app.1.log", "/logs/vm1.log"}; !slices.Equal(got, want) {
+		t.Fatalf("Filter = %q, want %q", got, want)
+	}
+	if u.CanRead("/logs/secret.log") {
+		t.Fatal("alice may read secret.log")
+	}
+	// Globs match base names, not directories.
+	if u.CanRead("/app.1.log/secret.log") {
+		t.Fatal("a glob matched a directory name")
+	}
+
+	var none *User
+	if got := none.Filter(files); !slices.Equal(got, files) {
+		t.Fatalf("Filter without an ACL = %q, want every file", got)
+	}
+	if got := (&User{Name: "bob"}).Filter(files); !slices.Equal(got, files) {
+		t.Fatalf("Filter for a user without globs = %q, want every file", got)
+	}
+}
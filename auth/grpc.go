@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type userKey struct{}
+
+// FromContext returns the user a handler is serving, or nil when the
+// worker has no ACL.
+func FromContext(ctx context.Context) *User {
+	u, _ := ctx.Value(userKey{}).(*User)
+	return u
+}
+
+// Require fails with PermissionDenied unless the caller may use mode.
+func Require(ctx context.Context, mode string) error {
+	u := FromContext(ctx)
+	if u.Allows(mode) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "user %s may not use %s mode", u.Name, mode)
+}
+
+// RequireAny fails with PermissionDenied unless the caller may use at
+// least one of modes.
+func RequireAny(ctx context.Context, modes ...string) error {
+	u := FromContext(ctx)
+	for _, m := range modes {
+		if u.Allows(m) {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "user %s may use none of the %s modes", u.Name, strings.Join(modes, ", "))
+}
+
+// Authenticate identifies the caller of an RPC: by the bearer token in
+// its authorization metadata if it sent one, otherwise by the common name
+// of its verified client certificate.
+func (a *ACL) Authenticate(ctx context.Context) (*User, error) {
+	if vals := metadata.ValueFromIncomingContext(ctx, "authorization"); len(vals) > 0 {
+		token, ok := strings.CutPrefix(vals[0], "Bearer ")
+		if !ok {
+			return nil, status.Errorf(codes.Unauthenticated, "authorization is not a bearer token")
+		}
+		if u := a.byToken(token); u != nil {
+			return u, nil
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "unknown token")
+	}
+	if cn, ok := certName(ctx); ok {
+		if u := a.users[cn]; u != nil {
+			return u, nil
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "certificate %q is not in the ACL", cn)
+	}
+	return nil, status.Errorf(codes.Unauthenticated, "no token or client certificate")
+}
+
+// certName returns the common name of the caller's verified client
+// certificate.
+func certName(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 {
+		return "", false
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName, true
+}
+
+// Identity names the caller for logs: its ACL user, or without an ACL the
+// name in its client certificate, or "" if it has neither.
+func Identity(ctx context.Context) string {
+	if u := FromContext(ctx); u != nil {
+		return u.Name
+	}
+	cn, _ := certName(ctx)
+	return cn
+}
+
+// UnaryInterceptor authenticates every unary call and makes the user
+// available to the handler through FromContext.
+func (a *ACL) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		u, err := a.Authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(context.WithValue(ctx, userKey{}, u), req)
+	}
+}
+
+// StreamInterceptor is UnaryInterceptor for streaming calls.
+func (a *ACL) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		u, err := a.Authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &userStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), userKey{}, u)})
+	}
+}
+
+type userStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *userStream) Context() context.Context { return s.ctx }
+
+// ServerOptions returns the interceptors that authenticate callers
+// against a. Handlers check modes and files themselves.
+func (a *ACL) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(a.UnaryInterceptor()), grpc.ChainStreamInterceptor(a.StreamInterceptor())}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func testACL(t *testing.T) *ACL {
+	t.Helper()
+	a, err := Load(writeACL(t,
+		"user.alice.token.sha256="+tokenHash("alice-secret"),
+		"user.bob.token.sha256="+tokenHash("bob-secret"),
+		"user.bob.modes=count",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func withAuthorization(value string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", value))
+}
+
+func TestAuthenticate(t *testing.T) {
+	a := testACL(t)
+	u, err := a.Authenticate(withAuthorization("Bearer alice-secret"))
+	if err != nil || u.Name != "alice" {
+		t.Fatalf("Authenticate with alice's token = %v, %v", u, err)
+	}
+	for name, ctx := range map[string]context.Context{
+		"unknown token":    withAuthorization("Bearer carol-secret"),
+		"not a bearer":     withAuthorization("Basic YWxpY2U6eA=="),
+		"no authorization": context.Background(),
+	} {
+		if u, err := a.Authenticate(ctx); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s: Authenticate = %v, %v; want Unauthenticated", name, u, err)
+		}
+	}
+}
+
+// handle runs a unary call with the given authorization through a's
+// interceptor to a handler that requires mode.
+func handle(a *ACL, authorization, mode string) error {
+	_, err := a.UnaryInterceptor()(withAuthorization(authorization), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+		return nil, Require(ctx, mode)
+	})
+	return err
+}
+
+func TestRequireModes(t *testing.T) {
+	a := testACL(t)
+	if err := handle(a, "Bearer bob-secret", ModeLines); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("count-only user in lines mode: %v, want PermissionDenied", err)
+	}
+	if err := handle(a, "Bearer bob-secret", ModeCount); err != nil {
+		t.Fatalf("count-only user in count mode: %v", err)
+	}
+	if err := handle(a, "Bearer alice-secret", ModeLines); err != nil {
+		t.Fatalf("default user in lines mode: %v", err)
+	}
+	if err := handle(a, "Bearer alice-secret", ModeCluster); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("default user in cluster mode: %v, want PermissionDenied", err)
+	}
+	if err := handle(a, "Bearer carol-secret", ModeCount); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unknown token: %v, want Unauthenticated", err)
+	}
+
+	bob := context.WithValue(context.Background(), userKey{}, a.byToken("bob-secret"))
+	if err := RequireAny(bob, ModeLines, ModeAggregate); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("RequireAny without a matching mode: %v, want PermissionDenied", err)
+	}
+	if err := RequireAny(bob, ModeLines, ModeCount); err != nil {
+		t.Fatalf("RequireAny with count: %v", err)
+	}
+	// Without an ACL there is no user, and everything is allowed.
+	if err := Require(context.Background(), ModeAudit); err != nil {
+		t.Fatalf("Require without an ACL: %v", err)
+	}
+}
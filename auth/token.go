@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+// ReadToken reads a bearer token from a file, ignoring surrounding
+// whitespace such as a trailing newline.
+func ReadToken(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	t := strings.TrimSpace(string(b))
+	if t == "" {
+		return "", fmt.Errorf("%s: empty token", path)
+	}
+	return t, nil
+}
+
+// bearer sends a token with every call. It refuses plaintext connections,
+// where the token could be read off the wire.
+type bearer string
+
+func (b bearer) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(b)}, nil
+}
+
+func (bearer) RequireTransportSecurity() bool { return true }
+
+// DialOption returns the option that sends token with every call on a
+// connection. The connection must use TLS.
+func DialOption(token string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(bearer(token))
+}
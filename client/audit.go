@@ -0,0 +1,68 @@
+package client
+
+import (
+	grep "MP1/protoBuilds"
+	"context"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuditLog is one worker's audit records, as reported by Audit.
+type AuditLog struct {
+	Target  Target
+	Records []*grep.AuditRecord // oldest first
+	Err     error
+}
+
+// Audit asks every worker, in parallel, for the records in its audit log
+// that match req. Each worker only knows the searches it served itself,
+// so there is no failing over to replica holders. The logs are in
+// Config.Targets order, followed by registered workers.
+func (c *Client) Audit(ctx context.Context, req *grep.AuditRequest) []AuditLog {
+	targets := c.cfg.Targets
+	if c.cfg.Discover {
+		_, joined := c.lookup(ctx)
+		targets = append(append([]Target(nil), targets...), joined...)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	out := make([]AuditLog, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			al := &out[i]
+			al.Target = t
+			al.Records, al.Err = c.audit(ctx, t, req)
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+func (c *Client) audit(ctx context.Context, t Target, req *grep.AuditRequest) ([]*grep.AuditRecord, error) {
+	conn, err := c.dial(ctx, t.Addr)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "dial: %v", err)
+	}
+	defer conn.Close()
+	stream, err := grep.NewGrepServiceClient(conn).Audit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var recs []*grep.AuditRecord
+	for {
+		r, err := stream.Recv()
+		if err == io.EOF {
+			return recs, nil
+		}
+		if err != nil {
+			return recs, err
+		}
+		recs = append(recs, r)
+	}
+}
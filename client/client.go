@@ -0,0 +1,457 @@
+// Package client issues a distributed search against every worker in the
+// cluster and streams back their results. The coordinator command is a thin
+// wrapper around it; other Go tools can import it directly.
+package client
+
+import (
+	"MP1/properties"
+	grep "MP1/protoBuilds"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Target is one worker to query.
+type Target struct {
+	Label string // name used in results, peer.machine.nameN
+	Addr  string // host:port
+}
+
+// Default timeouts, used when a Config or cluster.properties leaves them
+// unset.
+const (
+	DefaultConnectTimeout   = 2 * time.Second
+	DefaultFirstByteTimeout = 10 * time.Second
+	DefaultTimeout          = 60 * time.Second
+)
+
+// Config describes the cluster a Client talks to.
+type Config struct {
+	Targets []Target
+	// ConnectTimeout bounds connecting to each worker. Refused connections
+	// fail at once rather than waiting for it.
+	ConnectTimeout time.Duration
+	// FirstByteTimeout bounds the wait, once connected, for a worker to
+	// acknowledge the search.
+	FirstByteTimeout time.Duration
+	// Timeout bounds the whole search, dial included. Follow searches
+	// run until their context is cancelled instead.
+	Timeout time.Duration
+	// SkipFailed asks the workers' failure detector which workers have
+	// failed or left before each search, and does not dial them. Their
+	// shards go straight to replica holders, if any.
+	SkipFailed bool
+	// Discover also searches live workers that registered with the seeds
+	// in Targets without being listed there.
+	Discover bool
+	// ReplicationFactor is how many workers hold each shard, the primary
+	// included. Replicas live on the primary's successors in Targets
+	// order. Zero or one means no replicas.
+	ReplicationFactor int
+	// Buffer is how many results are buffered per worker. Zero means 256.
+	Buffer int
+	// BusyRetries is how many times a worker that refuses a search as busy
+	// (ResourceExhausted) is asked again, after the delay it suggests or an
+	// exponential backoff, before its replica holders are tried. Zero
+	// means DefaultBusyRetries; negative means none.
+	BusyRetries int
+	// DialOptions are added to the options used for every worker.
+	DialOptions []grpc.DialOption
+}
+
+// ConfigFromProps reads the worker list from cluster.properties:
+// no.of.machines and peer.machine.{ip,port,name}N for N from 0, plus the
+// optional timeout.connect.ms, timeout.firstbyte.ms, timeout.total.ms,
+// replication.factor and busy.retries.
+func ConfigFromProps(p properties.Props) (Config, error) {
+	n := p.Int("no.of.machines", 0)
+	if n <= 0 {
+		return Config{}, fmt.Errorf("no.of.machines missing or zero")
+	}
+	cfg := Config{
+		ConnectTimeout:   time.Duration(p.Int("timeout.connect.ms", 0)) * time.Millisecond,
+		FirstByteTimeout: time.Duration(p.Int("timeout.firstbyte.ms", 0)) * time.Millisecond,
+		Timeout:          time.Duration(p.Int("timeout.total.ms", 0)) * time.Millisecond,
+
+		ReplicationFactor: p.Int("replication.factor", 1),
+		BusyRetries:       p.Int("busy.retries", 0),
+	}
+	for i := 0; i < n; i++ {
+		ip := p[fmtKey("peer.machine.ip", i)]
+		port := p[fmtKey("peer.machine.port", i)]
+		name := p[fmtKey("peer.machine.name", i)]
+		if ip == "" || port == "" {
+			continue
+		}
+		cfg.Targets = append(cfg.Targets, Target{Label: name, Addr: ip + ":" + port})
+	}
+	return cfg, nil
+}
+
+// Successors returns the indexes of the workers that hold replicas of
+// worker i's logs.
+func (c Config) Successors(i int) []int {
+	n := len(c.Targets)
+	var out []int
+	for k := 1; k < c.ReplicationFactor && k < n; k++ {
+		out = append(out, (i+k)%n)
+	}
+	return out
+}
+
+// holders returns worker i followed by its replica holders.
+func (c Config) holders(i int) []Target {
+	out := []Target{c.Targets[i]}
+	for _, j := range c.Successors(i) {
+		out = append(out, c.Targets[j])
+	}
+	return out
+}
+
+func fmtKey(prefix string, i int) string {
+	return fmt.Sprintf("%s%d", prefix, i)
+}
+
+// Client runs searches against a fixed set of workers.
+type Client struct {
+	cfg Config
+}
+
+// New returns a Client for cfg.
+func New(cfg Config) *Client {
+	if cfg.ConnectTimeout <= 0 {
+		cfg.ConnectTimeout = DefaultConnectTimeout
+	}
+	if cfg.FirstByteTimeout <= 0 {
+		cfg.FirstByteTimeout = DefaultFirstByteTimeout
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = 256
+	}
+	if cfg.BusyRetries == 0 {
+		cfg.BusyRetries = DefaultBusyRetries
+	}
+	return &Client{cfg: cfg}
+}
+
+// Result is one response for one worker's shard.
+type Result struct {
+	Target          // the shard's primary worker
+	ServedBy Target // the worker that answered; a replica holder after failover
+	*grep.SearchResponse
+}
+
+// Status is how one worker's part of a search ended.
+type Status string
+
+const (
+	StatusOK          Status = "ok"
+	StatusUnreachable Status = "unreachable" // could not connect, or the connection dropped
+	StatusTimedOut    Status = "timed out"   // connected but did not finish in time
+	StatusError       Status = "grep error"  // the worker rejected or failed the search
+	StatusBusy        Status = "busy"        // the worker refused the search for load, even after retries
+	StatusCanceled    Status = "canceled"    // the caller cancelled the search
+)
+
+// WorkerSummary describes how one worker's part of a search went.
+type WorkerSummary struct {
+	Target
+	Status    Status
+	ServedBy  Target // who answered for the shard; zero if nobody did
+	Responses int64  // responses received
+	Count     int64  // sum of Count in count and aggregate modes, otherwise selected lines; context lines and repeat -o matches do not count
+	Latency   time.Duration
+	Err       error
+}
+
+// Outcome summarises a whole search.
+type Outcome string
+
+const (
+	Complete Outcome = "complete" // every worker answered
+	Partial  Outcome = "partial"  // some workers answered
+	Failed   Outcome = "failed"   // no worker answered
+)
+
+// OutcomeOf classifies a search from its worker summaries. With
+// requireAll, any worker that did not answer fails the whole search.
+func OutcomeOf(sums []WorkerSummary, requireAll bool) Outcome {
+	ok := 0
+	for _, w := range sums {
+		if w.Status == StatusOK {
+			ok++
+		}
+	}
+	switch {
+	case ok == len(sums):
+		return Complete
+	case ok == 0 || requireAll:
+		return Failed
+	}
+	return Partial
+}
+
+// errFirstByte is the cancellation cause when a worker connects but does
+// not acknowledge the search within FirstByteTimeout.
+var errFirstByte = errors.New("no response before first-byte timeout")
+
+// errMarkedFailed is the error for a worker skipped because the failure
+// detector reports it failed.
+var errMarkedFailed = errors.New("marked failed by membership")
+
+// classify maps an error from a worker call to a Status. parent is the
+// caller's context and ctx the per-worker one derived from it. Dial errors
+// are always StatusUnreachable and are handled by the caller.
+func classify(parent, ctx context.Context, err error) Status {
+	if parent.Err() == context.Canceled {
+		return StatusCanceled
+	}
+	if context.Cause(ctx) == errFirstByte {
+		return StatusTimedOut
+	}
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return StatusUnreachable
+	case codes.ResourceExhausted:
+		return StatusBusy
+	case codes.DeadlineExceeded:
+		return StatusTimedOut
+	case codes.Canceled:
+		return StatusCanceled
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return StatusTimedOut
+	}
+	return StatusError
+}
+
+// Search is a distributed search in flight. Consume either Results or
+// Streams, not both, then call Summary.
+type Search struct {
+	ctx     context.Context
+	streams []chan Result
+	summary []WorkerSummary
+	wg      sync.WaitGroup
+	once    sync.Once
+	results chan Result
+}
+
+// Search sends req to every worker. Cancelling ctx stops all of them.
+func (c *Client) Search(ctx context.Context, req *grep.SearchRequest) *Search {
+	var failed map[string]bool
+	var joined []Target
+	if c.cfg.SkipFailed || c.cfg.Discover {
+		failed, joined = c.lookup(ctx)
+		if !c.cfg.SkipFailed {
+			failed = nil
+		}
+		if !c.cfg.Discover {
+			joined = nil
+		}
+	}
+	n := len(c.cfg.Targets) + len(joined)
+	s := &Search{
+		ctx:     ctx,
+		streams: make([]chan Result, n),
+		summary: make([]WorkerSummary, n),
+	}
+	s.wg.Add(n)
+	for i := range n {
+		// Registered workers outside the seed list have no replicas.
+		var holders []Target
+		if i < len(c.cfg.Targets) {
+			holders = c.cfg.holders(i)
+		} else {
+			holders = []Target{joined[i-len(c.cfg.Targets)]}
+		}
+		s.streams[i] = make(chan Result, c.cfg.Buffer)
+		s.summary[i].Target = holders[0]
+		go c.searchWorker(ctx, req, holders, failed, &s.summary[i], s.streams[i], &s.wg)
+	}
+	return s
+}
+
+// searchWorker searches one shard, trying its holders in order: the
+// primary, then its replicas.
+func (c *Client) searchWorker(ctx context.Context, req *grep.SearchRequest, holders []Target, failed map[string]bool, sum *WorkerSummary, out chan<- Result, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer close(out)
+	start := time.Now()
+	defer func() { sum.Latency = time.Since(start) }()
+
+	parent := ctx
+	cancel := context.CancelFunc(func() {})
+	if !req.Follow {
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+	}
+	defer cancel()
+	// Try the primary, then each replica holder in ring order. Only a
+	// holder that has sent nothing yet may be replaced, so no shard is
+	// ever counted twice. A busy holder is retried first.
+	for k, holder := range holders {
+		started, st, err := false, StatusUnreachable, errMarkedFailed
+		if !failed[holder.Label] {
+			r := req
+			if k > 0 {
+				r = proto.Clone(req).(*grep.SearchRequest)
+				r.Shard = sum.Label
+			}
+			started, st, err = c.attempt(parent, ctx, holder, r, sum, out)
+			for n := 0; !started && n < c.cfg.BusyRetries; n++ {
+				wait, ok := busyDelay(err, n)
+				if !ok || !sleep(ctx, wait) {
+					break
+				}
+				started, st, err = c.attempt(parent, ctx, holder, r, sum, out)
+			}
+		}
+		if k > 0 && err != nil {
+			err = fmt.Errorf("replica %s: %w", holder.Label, err)
+		}
+		if sum.Err != nil && err != nil {
+			err = fmt.Errorf("%v; %w", sum.Err, err)
+		}
+		sum.Status, sum.Err = st, err
+		if err == nil {
+			sum.ServedBy = holder
+			return
+		}
+		if started || (st != StatusUnreachable && st != StatusTimedOut && st != StatusBusy) || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// attempt runs req against one worker, sending results for the shard
+// summarised by sum. started reports whether any response arrived.
+func (c *Client) attempt(parent, ctx context.Context, t Target, req *grep.SearchRequest, sum *WorkerSummary, out chan<- Result) (started bool, st Status, err error) {
+	ctx, cancelCause := context.WithCancelCause(ctx)
+	defer cancelCause(nil)
+	fail := func(what string, err error) (bool, Status, error) {
+		st := classify(parent, ctx, err)
+		if cause := context.Cause(ctx); cause == errFirstByte {
+			err = cause
+		}
+		return started, st, fmt.Errorf("%s: %w", what, err)
+	}
+
+	// Dial with its own, shorter deadline so a dead host is given up on
+	// quickly while the other workers keep streaming.
+	conn, err := c.dial(ctx, t.Addr)
+	if err != nil {
+		st := StatusUnreachable
+		if parent.Err() == context.Canceled {
+			st = StatusCanceled
+		}
+		return false, st, fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+
+	// Workers send response headers as soon as they accept a search, so
+	// waiting for them detects a hung worker even in count mode, where the
+	// only message comes at the end.
+	firstByte := time.AfterFunc(c.cfg.FirstByteTimeout, func() { cancelCause(errFirstByte) })
+	stream, err := grep.NewGrepServiceClient(conn).Search(ctx, req)
+	if err == nil {
+		_, err = stream.Header()
+	}
+	firstByte.Stop()
+	if err != nil {
+		return fail("search", err)
+	}
+	var lastFile string // the last selected line, to count -o matches once
+	var lastLine int64
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return started, StatusOK, nil
+		}
+		if err != nil {
+			return fail("recv", err)
+		}
+		started = true
+		sum.Responses++
+		switch {
+		case req.Mode == "count" || req.Mode == "aggregate":
+			sum.Count += resp.Count
+		case resp.Kind == grep.LineKind_MATCH && (resp.FilePath != lastFile || resp.LineNumber != lastLine):
+			sum.Count++
+			lastFile, lastLine = resp.FilePath, resp.LineNumber
+		}
+		r := Result{Target: sum.Target, ServedBy: t, SearchResponse: resp}
+		select {
+		case out <- r:
+		case <-ctx.Done():
+			return fail("recv", status.FromContextError(ctx.Err()).Err())
+		}
+	}
+}
+
+// dial connects to addr, giving up after ConnectTimeout.
+func (c *Client) dial(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.ConnectTimeout)
+	defer cancel()
+	opts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock(),
+		grpc.FailOnNonTempDialError(true),
+	}, c.cfg.DialOptions...)
+	return grpc.DialContext(ctx, addr, opts...)
+}
+
+// Streams returns one channel per worker, in Config.Targets order. Each is
+// closed when that worker is done.
+func (s *Search) Streams() []<-chan Result {
+	out := make([]<-chan Result, len(s.streams))
+	for i, c := range s.streams {
+		out[i] = c
+	}
+	return out
+}
+
+// Results returns all workers' results on one channel, in arrival order.
+// It is closed when every worker is done, or soon after the search's ctx
+// is, so a caller that stops reading should cancel ctx.
+func (s *Search) Results() <-chan Result {
+	s.once.Do(func() {
+		s.results = make(chan Result)
+		var wg sync.WaitGroup
+		wg.Add(len(s.streams))
+		for _, c := range s.streams {
+			go func(c chan Result) {
+				defer wg.Done()
+				for r := range c {
+					select {
+					case s.results <- r:
+					case <-s.ctx.Done():
+						return
+					}
+				}
+			}(c)
+		}
+		go func() {
+			wg.Wait()
+			close(s.results)
+		}()
+	})
+	return s.results
+}
+
+// Summary waits for every worker to finish and returns their summaries in
+// Config.Targets order.
+func (s *Search) Summary() []WorkerSummary {
+	s.wg.Wait()
+	return s.summary
+}
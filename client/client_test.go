@@ -0,0 +1,33 @@
+package client
+
+import (
+	grep "MP1/protoBuilds"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestResultsStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan Result, 3)
+	for range 3 {
+		c <- Result{SearchResponse: &grep.SearchResponse{Log: "x"}}
+	}
+	close(c)
+	s := &Search{ctx: ctx, streams: []chan Result{c}}
+	results := s.Results()
+	if _, ok := <-results; !ok {
+		t.Fatal("Results closed before the search was canceled")
+	}
+	// The caller gives up and stops reading.
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case r, ok := <-results:
+		if ok {
+			t.Fatalf("Results still sending %v after cancel", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Results not closed after cancel")
+	}
+}
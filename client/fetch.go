@@ -0,0 +1,190 @@
+package client
+
+import (
+	grep "MP1/protoBuilds"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Target returns the worker with the given label, looking among the
+// registered workers too when Discover is set.
+func (c *Client) Target(ctx context.Context, label string) (Target, bool) {
+	for _, t := range c.cfg.Targets {
+		if t.Label == label {
+			return t, true
+		}
+	}
+	if c.cfg.Discover {
+		_, joined := c.lookup(ctx)
+		for _, t := range joined {
+			if t.Label == label {
+				return t, true
+			}
+		}
+	}
+	return Target{}, false
+}
+
+// LinesAround fetches the line at a byte offset from a search result, with
+// context around it, from worker t. To follow up on a Result, use its
+// ServedBy worker and set req.Shard to the Result's Shard.
+func (c *Client) LinesAround(ctx context.Context, t Target, req *grep.LinesAroundRequest) ([]*grep.SearchResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	conn, err := c.dial(ctx, t.Addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stream, err := grep.NewGrepServiceClient(conn).LinesAround(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var out []*grep.SearchResponse
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, resp)
+	}
+}
+
+// ReadRange streams a range of one of shard's log files to fn, reading it
+// from the shard's primary or, if that is unreachable, from a replica
+// holder. It returns the worker that served the range.
+func (c *Client) ReadRange(ctx context.Context, shard string, req *grep.ReadRangeRequest, fn func(*grep.FileChunk) error) (Target, error) {
+	holders := c.holdersOf(ctx, shard)
+	if len(holders) == 0 {
+		return Target{}, fmt.Errorf("no worker %q", shard)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	r := proto.Clone(req).(*grep.ReadRangeRequest)
+	r.Shard = shard
+	return failover(ctx, holders, func(t Target) (bool, error) {
+		return c.readRange(ctx, t, r, fn)
+	})
+}
+
+// failover calls try on each of a shard's holders in turn until one
+// succeeds. It moves on only while holders are unreachable and nothing
+// has been received from them, and returns the holder that succeeded.
+func failover(ctx context.Context, holders []Target, try func(Target) (started bool, err error)) (Target, error) {
+	var errs error
+	for k, t := range holders {
+		started, err := try(t)
+		if err == nil {
+			return t, nil
+		}
+		if k > 0 {
+			err = fmt.Errorf("replica %s: %w", t.Label, err)
+		}
+		if errs != nil {
+			err = fmt.Errorf("%v; %w", errs, err)
+		}
+		errs = err
+		if started || ctx.Err() != nil || status.Code(err) != codes.Unavailable {
+			break
+		}
+	}
+	return Target{}, errs
+}
+
+// readRange runs req against worker t. started reports whether any chunk
+// arrived, after which the range cannot be retried elsewhere.
+func (c *Client) readRange(ctx context.Context, t Target, req *grep.ReadRangeRequest, fn func(*grep.FileChunk) error) (started bool, err error) {
+	conn, err := c.dial(ctx, t.Addr)
+	if err != nil {
+		return false, status.Errorf(codes.Unavailable, "dial: %v", err)
+	}
+	defer conn.Close()
+	stream, err := grep.NewGrepServiceClient(conn).ReadRange(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return started, nil
+		}
+		if err != nil {
+			return started, err
+		}
+		started = true
+		if err := fn(chunk); err != nil {
+			return true, err
+		}
+	}
+}
+
+// holdersOf returns the workers holding shard: its primary followed by
+// its replica holders, or only the worker itself if it registered through
+// discovery.
+func (c *Client) holdersOf(ctx context.Context, shard string) []Target {
+	for i, t := range c.cfg.Targets {
+		if t.Label == shard {
+			return c.cfg.holders(i)
+		}
+	}
+	if t, ok := c.Target(ctx, shard); ok {
+		return []Target{t}
+	}
+	return nil
+}
+
+// FileList is one shard's log files, as reported by ListFiles.
+type FileList struct {
+	Target   Target // the shard's primary
+	ServedBy Target // the worker that answered, a replica holder if the primary did not
+	*grep.ListFilesResponse
+	Err error
+}
+
+// ListFiles asks every shard, in parallel, which files a search would
+// read, falling back to replica holders for unreachable primaries. The
+// lists are in Config.Targets order, followed by registered workers.
+func (c *Client) ListFiles(ctx context.Context) []FileList {
+	var shards [][]Target
+	for i := range c.cfg.Targets {
+		shards = append(shards, c.cfg.holders(i))
+	}
+	if c.cfg.Discover {
+		_, joined := c.lookup(ctx)
+		for _, t := range joined {
+			shards = append(shards, []Target{t})
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	out := make([]FileList, len(shards))
+	var wg sync.WaitGroup
+	for i, holders := range shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fl := &out[i]
+			fl.Target = holders[0]
+			fl.ServedBy, fl.Err = failover(ctx, holders, func(t Target) (bool, error) {
+				conn, err := c.dial(ctx, t.Addr)
+				if err != nil {
+					return false, status.Errorf(codes.Unavailable, "dial: %v", err)
+				}
+				defer conn.Close()
+				fl.ListFilesResponse, err = grep.NewGrepServiceClient(conn).ListFiles(ctx, &grep.ListFilesRequest{Shard: holders[0].Label})
+				return false, err
+			})
+		}()
+	}
+	wg.Wait()
+	return out
+}
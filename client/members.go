@@ -0,0 +1,69 @@
+package client
+
+import (
+	grep "MP1/protoBuilds"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Members returns the cluster membership as seen by the first worker to
+// answer. Workers are asked in parallel, so dead ones cost nothing extra.
+func (c *Client) Members(ctx context.Context) ([]*grep.Member, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.ConnectTimeout+c.cfg.FirstByteTimeout)
+	defer cancel()
+	type answer struct {
+		members []*grep.Member
+		err     error
+	}
+	answers := make(chan answer, len(c.cfg.Targets))
+	for _, t := range c.cfg.Targets {
+		go func(t Target) {
+			conn, err := c.dial(ctx, t.Addr)
+			if err != nil {
+				answers <- answer{err: fmt.Errorf("%s: %w", t.Label, err)}
+				return
+			}
+			defer conn.Close()
+			resp, err := grep.NewGrepServiceClient(conn).Members(ctx, &grep.MembersRequest{})
+			if err != nil {
+				answers <- answer{err: fmt.Errorf("%s: %w", t.Label, err)}
+				return
+			}
+			answers <- answer{members: resp.Members}
+		}(t)
+	}
+	var errs []error
+	for range c.cfg.Targets {
+		a := <-answers
+		if a.err == nil {
+			return a.members, nil
+		}
+		errs = append(errs, a.err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// lookup asks the failure detector which seed workers have failed or left,
+// and which other workers have registered and are alive or suspect. If no
+// worker can tell, nobody is skipped or added.
+func (c *Client) lookup(ctx context.Context) (failed map[string]bool, joined []Target) {
+	members, err := c.Members(ctx)
+	if err != nil {
+		return nil, nil
+	}
+	seeds := map[string]bool{}
+	for _, t := range c.cfg.Targets {
+		seeds[t.Label] = true
+	}
+	failed = map[string]bool{}
+	for _, m := range members {
+		switch {
+		case m.State == grep.MemberState_FAILED || m.State == grep.MemberState_LEFT:
+			failed[m.Label] = true
+		case !seeds[m.Label]:
+			joined = append(joined, Target{Label: m.Label, Addr: m.Addr})
+		}
+	}
+	return failed, joined
+}
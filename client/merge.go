@@ -0,0 +1,86 @@
+package client
+
+import (
+	"MP1/search"
+	"container/heap"
+	"context"
+	"time"
+)
+
+// mergeItem is the head result of one worker stream.
+type mergeItem struct {
+	r      Result
+	stamp  time.Time
+	stream int
+	seq    int64
+}
+
+// mergeHeap orders the head result of each worker stream by timestamp,
+// then by arrival so lines with equal stamps keep their per-worker order.
+type mergeHeap []mergeItem
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if !h[i].stamp.Equal(h[j].stamp) {
+		return h[i].stamp.Before(h[j].stamp)
+	}
+	if h[i].stream != h[j].stream {
+		return h[i].stream < h[j].stream
+	}
+	return h[i].seq < h[j].seq
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(mergeItem)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// MergeByTime k-way merges worker streams, each already in file order,
+// into one chronological sequence using layout to read line timestamps.
+// It holds only the head result of every stream, so memory is bounded by
+// the streams' own buffers. Lines without a timestamp inherit the previous
+// stamp from the same file. The returned channel is closed when every
+// stream is drained or ctx is done.
+func MergeByTime(ctx context.Context, streams []<-chan Result, layout *search.TimeLayout) <-chan Result {
+	out := make(chan Result)
+	go func() {
+		defer close(out)
+		type state struct {
+			file  string
+			stamp time.Time
+			seq   int64
+		}
+		states := make([]state, len(streams))
+		h := &mergeHeap{}
+		next := func(i int) {
+			for r := range streams[i] {
+				st := &states[i]
+				if r.FilePath != st.file {
+					st.file, st.stamp = r.FilePath, time.Time{}
+				}
+				if t, ok := layout.Parse([]byte(r.Log)); ok {
+					st.stamp = t
+				}
+				st.seq++
+				heap.Push(h, mergeItem{r: r, stamp: st.stamp, stream: i, seq: st.seq})
+				return
+			}
+		}
+		for i := range streams {
+			next(i)
+		}
+		for h.Len() > 0 {
+			it := heap.Pop(h).(mergeItem)
+			select {
+			case out <- it.r:
+			case <-ctx.Done():
+				return
+			}
+			next(it.stream)
+		}
+	}()
+	return out
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	grep "MP1/protoBuilds"
+	"MP1/search"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stream sends the lines of files, in order, as one worker's results.
+func stream(t *testing.T, host string, files []string) <-chan Result {
+	t.Helper()
+	var rs []Result
+	for _, fp := range files {
+		r, _, err := search.Open(fp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc := bufio.NewScanner(r)
+		for n := int64(1); sc.Scan(); n++ {
+			rs = append(rs, Result{SearchResponse: &grep.SearchResponse{Host: host, FilePath: fp, LineNumber: n, Log: sc.Text()}})
+		}
+		r.Close()
+	}
+	ch := make(chan Result, len(rs))
+	for _, r := range rs {
+		ch <- r
+	}
+	close(ch)
+	return ch
+}
+
+func TestMergeByTimeWithRotatedArchive(t *testing.T) {
+	dir := t.TempDir()
+	stamp := func(h int) string {
+		return time.Date(2024, 1, 1, h, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	}
+	write := func(name string, hours ...int) {
+		var text string
+		for _, h := range hours {
+			text += stamp(h) + " event\n"
+		}
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if filepath.Ext(name) == ".gz" {
+			zw := gzip.NewWriter(f)
+			defer zw.Close()
+			zw.Write([]byte(text))
+			return
+		}
+		f.WriteString(text)
+	}
+	// vm1's log was rotated twice; vm2's was not.
+	write("vm1.log.2.gz", 1, 3)
+	write("vm1.log.1", 5, 7)
+	write("vm1.log", 9, 11)
+	write("vm2.other", 2, 4, 6, 8, 10, 12)
+
+	files, err := search.Files(dir, "vm1.log", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout, err := search.ParseTimeLayout("rfc3339")
+	if err != nil {
+		t.Fatal(err)
+	}
+	streams := []<-chan Result{
+		stream(t, "vm1", files),
+		stream(t, "vm2", []string{filepath.Join(dir, "vm2.other")}),
+	}
+	var got []string
+	for r := range MergeByTime(context.Background(), streams, layout) {
+		got = append(got, r.Log)
+	}
+	if len(got) != 12 {
+		t.Fatalf("merged %d lines, want 12: %q", len(got), got)
+	}
+	for i, line := range got {
+		if want := stamp(i+1) + " event"; line != want {
+			t.Errorf("line %d = %q, want %q", i, line, want)
+		}
+	}
+}
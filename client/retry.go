@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Backoff between retries of a busy worker, when it gives no hint or a
+// shorter one.
+const (
+	busyBackoff    = 200 * time.Millisecond
+	maxBusyBackoff = 10 * time.Second
+)
+
+// DefaultBusyRetries is how often a busy worker is retried when a Config
+// leaves BusyRetries unset.
+const DefaultBusyRetries = 3
+
+// busyDelay reports whether err is a worker refusing a search because it
+// is busy, and how long to wait before retry n (counting from 0): the
+// worker's RetryInfo hint or an exponential backoff, whichever is longer,
+// stretched by up to a fifth so clients told the same thing do not all
+// come back at once.
+func busyDelay(err error, n int) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.ResourceExhausted {
+		return 0, false
+	}
+	d := busyBackoff << min(n, 10)
+	for _, detail := range st.Details() {
+		if ri, ok := detail.(*errdetails.RetryInfo); ok && ri.RetryDelay.AsDuration() > d {
+			d = ri.RetryDelay.AsDuration()
+		}
+	}
+	d = min(d, maxBusyBackoff)
+	return d + rand.N(d/5+1), true
+}
+
+// sleep waits for d unless ctx would end first, and reports whether it
+// waited.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	grep "MP1/protoBuilds"
+	"MP1/search"
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// parseGroupBy parses a -group-by value: group:N or group:NAME for a
+// capture group of the patterns (group:0 is the whole match), or field:N
+// or field:N:SEP for a field of the line (field:0 is the whole line).
+func parseGroupBy(spec string) (*grep.Aggregation, error) {
+	kind, rest, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "group":
+		if rest == "" {
+			break
+		}
+		if n, err := strconv.Atoi(rest); err == nil {
+			return &grep.Aggregation{By: grep.GroupBy_CAPTURE, Index: int32(n)}, nil
+		}
+		return &grep.Aggregation{By: grep.GroupBy_CAPTURE, Name: rest}, nil
+	case "field":
+		num, sep, _ := strings.Cut(rest, ":")
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			break
+		}
+		return &grep.Aggregation{By: grep.GroupBy_FIELD, Index: int32(n), Separator: sep}, nil
+	}
+	return nil, fmt.Errorf("-group-by wants group:N, group:NAME, field:N or field:N:SEP, got %q", spec)
+}
+
+// checkGroupBy makes sure workers will accept agg with the grep options
+// in opts, so mistakes are reported once rather than by every worker.
+func checkGroupBy(opts search.Options, agg *grep.Aggregation) error {
+	sr, err := search.New(opts)
+	if err != nil {
+		return err
+	}
+	g, err := search.FromAggregation(agg)
+	if err == nil {
+		_, err = sr.Keyer(g)
+	}
+	if err != nil {
+		return fmt.Errorf("-group-by: %v", err)
+	}
+	return nil
+}
+
+// groupTable merges the workers' partial counts per key.
+type groupTable struct {
+	counts map[string]int64
+	other  int64 // lines whose key a worker did not keep
+}
+
+func (t *groupTable) add(r *grep.SearchResponse) {
+	for _, g := range r.Groups {
+		t.counts[g.Key] += g.Count
+	}
+	t.other += r.OtherCount
+}
+
+// top returns the k keys with the highest counts, or all of them when k
+// is 0, most frequent first and ties in key order.
+func (t *groupTable) top(k int) []groupRecord {
+	out := make([]groupRecord, 0, len(t.counts))
+	for key, n := range t.counts {
+		out = append(out, groupRecord{Type: "group", Key: key, Count: n})
+	}
+	slices.SortFunc(out, func(a, b groupRecord) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	if k > 0 && len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"MP1/client"
+	grep "MP1/protoBuilds"
+	"MP1/search"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// runAudit prints the searches the workers' audit logs record, across the
+// cluster in time order, and returns the exit code.
+func runAudit(c *client.Client, args []string, w io.Writer) int {
+	fl := flag.NewFlagSet("audit", flag.ContinueOnError)
+	since := fl.String("since", "24h", "only searches started at or after this time (same formats as the search -since)")
+	until := fl.String("until", "", "only searches started at or before this time")
+	user := fl.String("user", "", "only searches by this user")
+	n := fl.Int("n", 100, "only the newest N searches of each worker; 0 for all")
+	asJSON := fl.Bool("json", false, "print one JSON record per line instead of a table")
+	if err := fl.Parse(args); err != nil || fl.NArg() > 0 {
+		return 2
+	}
+	req := &grep.AuditRequest{User: *user, Limit: int32(*n)}
+	now := time.Now()
+	for _, tf := range []struct {
+		arg string
+		dst **timestamppb.Timestamp
+	}{{*since, &req.Since}, {*until, &req.Until}} {
+		if tf.arg == "" {
+			continue
+		}
+		t, err := search.ParseTimeArg(tf.arg, now)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "audit:", err)
+			return 2
+		}
+		*tf.dst = timestamppb.New(t)
+	}
+
+	logs := c.Audit(context.Background(), req)
+	var recs []*grep.AuditRecord
+	failed := 0
+	for _, l := range logs {
+		if l.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %v\n", l.Target.Label, l.Err)
+		}
+		recs = append(recs, l.Records...)
+	}
+	slices.SortStableFunc(recs, func(a, b *grep.AuditRecord) int {
+		return a.Time.AsTime().Compare(b.Time.AsTime())
+	})
+	if *asJSON {
+		for _, r := range recs {
+			b, err := protojson.Marshal(r)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "audit:", err)
+				return 1
+			}
+			fmt.Fprintf(w, "%s\n", b)
+		}
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "TIME\tWORKER\tUSER\tPEER\tMODE\tQUERY\tFILES\tLINES\tCOUNT\tMS\tRESULT")
+		for _, r := range recs {
+			result := r.Code
+			if r.Error != "" {
+				result += ": " + r.Error
+			}
+			user := r.User
+			if user == "" {
+				user = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", r.Time.AsTime().Local().Format("2006-01-02 15:04:05"),
+				r.Host, user, r.Peer, auditMode(r.Request), describeQuery(r.Request), len(r.Files), r.Lines, r.Count, r.DurationMs, result)
+		}
+		tw.Flush()
+	}
+	switch {
+	case failed == len(logs):
+		return exitFailed
+	case failed > 0:
+		return exitPartial
+	}
+	return 0
+}
+
+// auditMode names the kind of search req asked for.
+func auditMode(req *grep.SearchRequest) string {
+	mode := req.GetMode()
+	if mode == "" {
+		mode = "lines"
+	}
+	if req.GetFollow() {
+		mode += "+follow"
+	}
+	return mode
+}
+
+// describeQuery writes req's search back as grep arguments.
+func describeQuery(req *grep.SearchRequest) string {
+	q := req.GetQuery()
+	if q == nil {
+		return strings.Join(req.GetGrepOptions(), " ")
+	}
+	var args []string
+	switch q.Syntax {
+	case grep.PatternSyntax_FIXED:
+		args = append(args, "-F")
+	case grep.PatternSyntax_EXTENDED:
+		args = append(args, "-E")
+	case grep.PatternSyntax_PCRE:
+		args = append(args, "-P")
+	}
+	for _, f := range []struct {
+		on   bool
+		flag string
+	}{{q.IgnoreCase, "-i"}, {q.Invert, "-v"}, {q.WholeWord, "-w"}, {q.LineRegexp, "-x"}, {q.LineNumbers, "-n"}, {q.OnlyMatching, "-o"}} {
+		if f.on {
+			args = append(args, f.flag)
+		}
+	}
+	for _, f := range []struct {
+		n    int64
+		flag string
+	}{{q.MaxCount, "-m"}, {int64(q.BeforeContext), "-B"}, {int64(q.AfterContext), "-A"}} {
+		if f.n > 0 {
+			args = append(args, f.flag, strconv.FormatInt(f.n, 10))
+		}
+	}
+	for _, p := range q.Patterns {
+		args = append(args, "-e", strconv.Quote(p))
+	}
+	return strings.Join(args, " ")
+}
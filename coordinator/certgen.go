@@ -0,0 +1,112 @@
+package main
+
+import (
+	"MP1/client"
+	"MP1/properties"
+	"MP1/tlsutil"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// certNode is one certificate certgen issues.
+type certNode struct {
+	label string
+	hosts []string
+}
+
+// runCertgen creates a CA for a test cluster, or reuses the one in the
+// output directory, and issues a certificate for every node and one for
+// the coordinator. Nodes are given as label=host,host arguments, or else
+// taken from the seeds in propsPath. It returns the exit code.
+func runCertgen(propsPath string, args []string) int {
+	fl := flag.NewFlagSet("certgen", flag.ContinueOnError)
+	out := fl.String("out", "certs", "directory to write ca.crt, ca.key and <label>.crt/.key to")
+	days := fl.Int("days", 365, "validity of new certificates, in days")
+	if err := fl.Parse(args); err != nil {
+		return 2
+	}
+	nodes, err := certNodes(propsPath, fl.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "certgen:", err)
+		return 2
+	}
+	validFor := time.Duration(*days) * 24 * time.Hour
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fmt.Fprintln(os.Stderr, "certgen:", err)
+		return 1
+	}
+	caCert, caKey := filepath.Join(*out, "ca.crt"), filepath.Join(*out, "ca.key")
+	ca, err := tlsutil.LoadCA(caCert, caKey)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if ca, err = tlsutil.NewCA(validFor); err == nil {
+			err = ca.WriteFiles(caCert, caKey)
+		}
+		if err == nil {
+			fmt.Fprintln(os.Stderr, "created", caCert)
+		}
+	case err == nil:
+		fmt.Fprintln(os.Stderr, "using", caCert)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "certgen:", err)
+		return 1
+	}
+	for _, n := range append(nodes, certNode{label: "coordinator", hosts: []string{"localhost", "127.0.0.1"}}) {
+		cert, key := filepath.Join(*out, n.label+".crt"), filepath.Join(*out, n.label+".key")
+		if err := ca.Issue(n.label, n.hosts, validFor, cert, key); err != nil {
+			fmt.Fprintln(os.Stderr, "certgen:", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "created %s for %s\n", cert, strings.Join(n.hosts, ", "))
+	}
+	return 0
+}
+
+// certNodes parses label=host,host arguments or, without any, lists the
+// seeds in propsPath with their address and name. Every node's
+// certificate also covers localhost, so a test cluster can run on one
+// machine.
+func certNodes(propsPath string, args []string) ([]certNode, error) {
+	var nodes []certNode
+	for _, a := range args {
+		label, hosts, ok := strings.Cut(a, "=")
+		if !ok || label == "" || hosts == "" {
+			return nil, fmt.Errorf("want label=host,host, got %q", a)
+		}
+		nodes = append(nodes, certNode{label: label, hosts: strings.Split(hosts, ",")})
+	}
+	if len(nodes) == 0 {
+		p, err := properties.Load(propsPath)
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := client.ConfigFromProps(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range cfg.Targets {
+			host, _, err := net.SplitHostPort(t.Addr)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, certNode{label: t.Label, hosts: []string{host, t.Label}})
+		}
+	}
+	for i := range nodes {
+		for _, h := range []string{"localhost", "127.0.0.1"} {
+			if !slices.Contains(nodes[i].hosts, h) {
+				nodes[i].hosts = append(nodes[i].hosts, h)
+			}
+		}
+	}
+	return nodes, nil
+}
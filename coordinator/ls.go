@@ -0,0 +1,66 @@
+package main
+
+import (
+	"MP1/client"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+)
+
+// runLs prints the files every worker would search, with their size,
+// modification time, compression and line count, and returns the exit
+// code.
+func runLs(c *client.Client, w io.Writer) int {
+	lists := c.ListFiles(context.Background())
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORKER\tSERVED BY\tFILE\tSIZE\tMODIFIED\tCOMPRESSION\tLINES")
+	failed := 0
+	for _, l := range lists {
+		if l.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %v\n", l.Target.Label, l.Err)
+			continue
+		}
+		if len(l.Files) == 0 {
+			pattern := filepath.Join(l.Dir, l.Glob)
+			fmt.Fprintf(tw, "%s\t%s\t(no files match %s)\t\t\t\t\n", l.Target.Label, l.ServedBy.Label, pattern)
+			continue
+		}
+		for _, f := range l.Files {
+			lines := fmt.Sprintf("~%d", f.Lines)
+			if f.LinesExact {
+				lines = fmt.Sprint(f.Lines)
+			}
+			if f.Error != "" {
+				lines = "error: " + f.Error
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", l.Target.Label, l.ServedBy.Label, filepath.Base(f.Path),
+				humanSize(f.Size), f.ModTime.AsTime().Local().Format("2006-01-02 15:04:05"), f.Compression, lines)
+		}
+	}
+	tw.Flush()
+	switch {
+	case failed == len(lists):
+		return exitFailed
+	case failed > 0:
+		return exitPartial
+	}
+	return 0
+}
+
+// humanSize formats a byte count the way ls -h does.
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
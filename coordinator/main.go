@@ -1,121 +1,327 @@
 package main
 
 import (
+	"MP1/auth"
+	"MP1/client"
 	"MP1/properties"
 	grep "MP1/protoBuilds"
+	"MP1/search"
+	"MP1/tlsutil"
 	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"sync"
-	"sync/atomic"
+	"syscall"
 	"time"
 
-	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Exit codes. 1 and 2 are used for configuration and usage errors.
+const (
+	exitPartial = 3 // some workers did not answer
+	exitFailed  = 4 // no worker answered, or one did not with -require-all
 )
 
 func main() {
 	propsPath := flag.String("props", "cluster.properties", "Path to properties file")
-	mode := flag.String("mode", "lines", "lines or count")
+	mode := flag.String("mode", "lines", "lines, count or aggregate (set by -group-by)")
+	since := flag.String("since", "", "only lines stamped at or after this time (RFC 3339, \"2006-01-02 15:04:05\", \"15:04\", or a duration like 30m)")
+	until := flag.String("until", "", "only lines stamped at or before this time (same formats as -since)")
+	merge := flag.Bool("merge", false, "in lines mode, print lines from all workers in timestamp order")
+	mergeBuf := flag.Int("merge-buffer", 256, "results buffered per worker, bounding memory while merging")
+	timeFmt := flag.String("timefmt", "apache", "log timestamp layout used by -merge: syslog, rfc3339, apache, or a Go time layout")
+	format := flag.String("format", "text", "output format: text, json, ndjson or csv")
+	requireAll := flag.Bool("require-all", false, "fail the query if any worker does not answer")
+	connectTimeout := flag.Duration("connect-timeout", 0, "time allowed to connect to each worker (default timeout.connect.ms or 2s)")
+	firstByteTimeout := flag.Duration("first-byte-timeout", 0, "time allowed for a worker to acknowledge the search (default timeout.firstbyte.ms or 10s)")
+	totalTimeout := flag.Duration("timeout", 0, "time allowed for the whole query (default timeout.total.ms or 60s)")
+	skipFailed := flag.Bool("skip-failed", true, "do not dial workers the failure detector reports failed or gone")
+	discover := flag.Bool("discover", true, "also search workers that registered with the seeds in -props")
+	follow := flag.Bool("follow", false, "keep streaming newly written matching lines from every worker until Ctrl-C, like tail -F | grep")
+	limit := flag.Int64("limit", 0, "in lines mode, stop once this many matching lines have been printed from the whole cluster")
+	groupBy := flag.String("group-by", "", "count matching lines per key, a capture group or field: group:N, group:NAME, field:N or field:N:SEP")
+	top := flag.Int("top", 0, "with -group-by, print only the K most frequent keys")
+	maxGroups := flag.Int("max-groups", 0, "with -group-by, keys each worker keeps; past it a new key replaces the least frequent one, whose lines count as other (default and cap 100000)")
+	metricsFile := flag.String("metrics-file", "", "write the query's per-worker latency in Prometheus text format to this file, for node_exporter's textfile collector")
+	matrix := flag.Bool("matrix", false, "in count mode with text output, print a table of counts by file and host")
+	flagTLS := tlsutil.Files{}
+	flag.StringVar(&flagTLS.Cert, "tls-cert", "", "PEM client certificate for mutual TLS (default tls.cert in -props)")
+	flag.StringVar(&flagTLS.Key, "tls-key", "", "PEM key for -tls-cert (default tls.key in -props)")
+	flag.StringVar(&flagTLS.CA, "tls-ca", "", "PEM CA to verify workers with; setting any TLS file turns TLS on (default tls.ca in -props)")
+	tokenFile := flag.String("token-file", "", "file with the bearer token to send workers; needs TLS (default $DGREP_TOKEN, then auth.token.file in -props)")
 	flag.Parse()
 
 	args := flag.Args()
+	if len(args) > 0 && args[0] == "certgen" {
+		os.Exit(runCertgen(*propsPath, args[1:]))
+	}
+	if len(args) == 1 && args[0] == "members" {
+		cfg := loadConfig(*propsPath, *connectTimeout, *firstByteTimeout, *totalTimeout, flagTLS, *tokenFile)
+		os.Exit(runMembers(client.New(cfg), os.Stdout))
+	}
+	if len(args) == 1 && args[0] == "ls" {
+		cfg := loadConfig(*propsPath, *connectTimeout, *firstByteTimeout, *totalTimeout, flagTLS, *tokenFile)
+		cfg.Discover = *discover
+		os.Exit(runLs(client.New(cfg), os.Stdout))
+	}
+	if len(args) == 2 && args[0] == "show" {
+		cfg := loadConfig(*propsPath, *connectTimeout, *firstByteTimeout, *totalTimeout, flagTLS, *tokenFile)
+		cfg.Discover = *discover
+		os.Exit(runShow(client.New(cfg), args[1], os.Stdout))
+	}
+	if len(args) > 0 && args[0] == "audit" {
+		cfg := loadConfig(*propsPath, *connectTimeout, *firstByteTimeout, *totalTimeout, flagTLS, *tokenFile)
+		cfg.Discover = *discover
+		os.Exit(runAudit(client.New(cfg), args[1:], os.Stdout))
+	}
 	if len(args) > 0 && args[0] == "--" {
 		args = args[1:]
 	}
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "usage: grpccoordinator -props file -mode lines|count -- <grep options>")
+		fmt.Fprintln(os.Stderr, "usage: grpccoordinator -props file -mode lines|count [-group-by spec -top K] -format text|json|ndjson|csv -- <grep options>")
+		fmt.Fprintln(os.Stderr, "       grpccoordinator -props file members")
+		fmt.Fprintln(os.Stderr, "       grpccoordinator -props file ls")
+		fmt.Fprintln(os.Stderr, "       grpccoordinator -props file audit [-since T] [-until T] [-user U] [-n N] [-json]")
+		fmt.Fprintln(os.Stderr, "       grpccoordinator -props file certgen [-out dir] [-days N] [label=host,host ...]")
+		fmt.Fprintln(os.Stderr, "       grpccoordinator -props file show host:file:line[+N|-N|+-N]")
 		os.Exit(2)
 	}
-
-	p, err := properties.Load(*propsPath)
+	// Validate the grep options here and send workers the typed query built
+	// from them rather than the raw argument list.
+	opts, err := search.ParseArgs(args)
+	if err == nil {
+		_, err = search.Compile(opts)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "grep options:", err)
+		os.Exit(2)
+	}
+	out, err := newOutput(*format, os.Stdout)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(2)
 	}
-	//fmt.Fprintf(os.Stderr, "loaded properties from %s: %v\n", *propsPath, p)
-	n := p.Int("no.of.machines", 0)
-	if n <= 0 {
-		fmt.Fprintln(os.Stderr, "no.of.machines missing or zero")
-		os.Exit(1)
+	if opts.Count {
+		*mode = "count"
+	}
+	var agg *grep.Aggregation
+	switch {
+	case *groupBy != "":
+		if *mode == "count" {
+			fmt.Fprintln(os.Stderr, "-group-by cannot be used with count mode")
+			os.Exit(2)
+		}
+		if agg, err = parseGroupBy(*groupBy); err == nil {
+			err = checkGroupBy(opts, agg)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		agg.MaxGroups = int32(*maxGroups)
+		*mode = "aggregate"
+	case *mode == "aggregate" || *top != 0 || *maxGroups != 0:
+		fmt.Fprintln(os.Stderr, "-mode aggregate, -top and -max-groups need -group-by")
+		os.Exit(2)
+	}
+	if *top < 0 || *maxGroups < 0 {
+		fmt.Fprintln(os.Stderr, "-top and -max-groups must not be negative")
+		os.Exit(2)
+	}
+	if *follow && (*mode != "lines" || *merge) {
+		fmt.Fprintln(os.Stderr, "-follow needs lines mode and cannot be used with -merge")
+		os.Exit(2)
+	}
+	if *limit < 0 || (*limit > 0 && *mode != "lines") {
+		fmt.Fprintln(os.Stderr, "-limit needs lines mode and a positive count")
+		os.Exit(2)
+	}
+	if *matrix {
+		if *mode != "count" || *format != "text" {
+			fmt.Fprintln(os.Stderr, "-matrix needs count mode and text output")
+			os.Exit(2)
+		}
+		out = &matrixOutput{textOutput: out.(*textOutput), counts: map[string]map[string]int64{}}
+	}
+	var layout *search.TimeLayout
+	if *merge {
+		if layout, err = search.ParseTimeLayout(*timeFmt); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
 	}
 
-	targets := make([]string, 0, n)
-	labels := make([]string, 0, n)
+	cfg := loadConfig(*propsPath, *connectTimeout, *firstByteTimeout, *totalTimeout, flagTLS, *tokenFile)
+	cfg.Buffer = *mergeBuf
+	cfg.SkipFailed = *skipFailed
+	cfg.Discover = *discover
 
-	for i := 0; i < n; i++ {
-		ip := p[fmtKey("peer.machine.ip", i)]
-		port := p[fmtKey("peer.machine.port", i)]
-		name := p[fmtKey("peer.machine.name", i)]
-		if ip == "" || port == "" {
+	// Each worker need send no more than the whole cluster may print.
+	req := &grep.SearchRequest{Query: opts.Query(), Mode: *mode, Follow: *follow, MaxResults: *limit, Aggregate: agg}
+	now := time.Now()
+	for _, tf := range []struct {
+		arg string
+		dst **timestamppb.Timestamp
+	}{{*since, &req.Since}, {*until, &req.Until}} {
+		if tf.arg == "" {
 			continue
 		}
-		targets = append(targets, ip+":"+port)
-		labels = append(labels, name)
+		t, err := search.ParseTimeArg(tf.arg, now)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		*tf.dst = timestamppb.New(t)
+	}
+	if req.Since != nil && req.Until != nil && req.Until.AsTime().Before(req.Since.AsTime()) {
+		fmt.Fprintln(os.Stderr, "-until is before -since")
+		os.Exit(2)
 	}
 
-	req := &grep.SearchRequest{GrepOptions: args, Mode: *mode}
-
-	var wg sync.WaitGroup
-	wg.Add(len(targets))
-	var total int64
-
-	// DEBUG
-	//fmt.Fprintf(os.Stderr, "targets=%v labels=%v args=%v mode=%s\n", targets, labels, args, *mode)
+	// Ctrl-C stops the query; for -follow it is the normal way to end it.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Reaching -limit cancels the workers still streaming.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var printed int64
 	overallStart := time.Now()
-	for i, target := range targets {
-		hostLabel := labels[i]
-		go func(target, label string) {
-			fmt.Printf("In goroutine for %s (%s)\n", label, target) // DEBUG
-			defer wg.Done()
-			workerStart := time.Now()
-			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-			defer cancel()
-			conn, err := grpc.DialContext(ctx, target, grpc.WithInsecure(), grpc.WithBlock())
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "[%s] dial: %v\n", label, err)
-				return
+	srch := client.New(cfg).Search(ctx, req)
+	// Count and plain lines print in arrival order; with -merge the
+	// per-worker streams are merged by timestamp first.
+	var results <-chan client.Result
+	if *merge && *mode == "lines" {
+		results = client.MergeByTime(ctx, srch.Streams(), layout)
+	} else {
+		results = srch.Results()
+	}
+	groups := &groupTable{counts: map[string]int64{}}
+	for r := range results {
+		if *limit > 0 && printed >= *limit {
+			continue // draining after cancel
+		}
+		if *mode == "aggregate" {
+			groups.add(r.SearchResponse)
+			continue
+		}
+		if *mode == "count" {
+			rec := countRecord{Type: "count", Host: r.Label, Count: r.Count}
+			for _, fc := range r.FileCounts {
+				rec.Files = append(rec.Files, fileCount{File: filepath.Base(fc.FilePath), Count: fc.Count})
 			}
-			defer conn.Close()
-			cli := grep.NewGrepServiceClient(conn)
-			stream, err := cli.Search(ctx, req)
-			fmt.Printf("[%s] sent search request %+v\n", label, req) // DEBUG
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "[%s] search: %v\n", label, err)
-				return
+			out.count(rec)
+			continue
+		}
+		fp := r.FilePath
+		if fp == "" {
+			fp = r.Label
+		}
+		kind := "match"
+		if r.Kind == grep.LineKind_CONTEXT {
+			kind = "context"
+		}
+		out.line(lineRecord{Type: "line", Host: r.Label, File: filepath.Base(fp), Line: r.LineNumber, Offset: r.ByteOffset, Kind: kind, Hunk: r.Hunk, Text: r.Log})
+		if r.Kind == grep.LineKind_MATCH {
+			if printed++; printed == *limit {
+				cancel()
 			}
-			for {
-				resp, err := stream.Recv()
-				if err != nil {
-					if err.Error() != "EOF" {
-						fmt.Fprintf(os.Stderr, "[%s] recv error: %v\n", label, err)
-					}
-					break
-				}
-				fmt.Printf("[%s] got response: %+v\n", label, resp) // DEBUG
-				if *mode == "count" {
-					fmt.Printf("[%s] count=%d\n", label, resp.Count)
-					atomic.AddInt64(&total, resp.Count)
-					continue
-				}
-				fp := resp.FilePath
-				if fp == "" {
-					fp = label
-				}
-				fmt.Printf("[%s] %s:%s\n", label, filepath.Base(fp), resp.Log)
+		}
+	}
+	limited := *limit > 0 && printed >= *limit
+
+	var total int64
+	var summaries []workerSummary
+	sums := srch.Summary()
+	// Workers cut off by Ctrl-C in follow mode or by -limit did nothing wrong.
+	if (*follow || limited) && ctx.Err() != nil {
+		for i := range sums {
+			if sums[i].Status == client.StatusCanceled {
+				sums[i].Status, sums[i].Err = client.StatusOK, nil
 			}
-			duration := time.Since(workerStart).Milliseconds()
-			fmt.Fprintf(os.Stderr, "[%s] WORKER_MS=%d\n", label, duration)
-		}(target, hostLabel)
+		}
+	}
+	for _, w := range sums {
+		ws := workerSummary{Host: w.Label, Addr: w.Addr, Status: string(w.Status), Count: w.Count, LatencyMS: w.Latency.Milliseconds()}
+		if w.ServedBy.Label != "" && w.ServedBy != w.Target {
+			ws.ServedBy = w.ServedBy.Label
+		}
+		if w.Err != nil {
+			ws.Error = w.Err.Error()
+		}
+		total += w.Count
+		summaries = append(summaries, ws)
+	}
+	outcome := client.OutcomeOf(sums, *requireAll)
+	printStatusTable(os.Stderr, summaries)
+	// Groups can only be ranked once every worker's partial counts are in.
+	for _, g := range groups.top(*top) {
+		out.group(g)
+	}
+	elapsed := time.Since(overallStart)
+	out.summary(summaryRecord{Type: "summary", Mode: *mode, Outcome: string(outcome), Total: total, Other: groups.other, ElapsedMS: elapsed.Milliseconds(), Workers: summaries})
+	if *metricsFile != "" {
+		if err := writeMetrics(*metricsFile, *mode, outcome, elapsed, sums); err != nil {
+			fmt.Fprintln(os.Stderr, "metrics:", err)
+		}
 	}
-	wg.Wait()
-	fmt.Fprintf(os.Stderr, "OVERALL_MS=%d\n", time.Since(overallStart).Milliseconds())
-	if *mode == "count" {
-		fmt.Printf("TOTAL_COUNT=%d\n", total)
+	switch outcome {
+	case client.Partial:
+		os.Exit(exitPartial)
+	case client.Failed:
+		os.Exit(exitFailed)
 	}
 }
 
-func fmtKey(prefix string, i int) string {
-	return fmt.Sprintf("%s%d", prefix, i)
+// loadConfig reads the cluster from propsPath, exiting on error. Timeout,
+// TLS and token flags override cluster.properties when set.
+func loadConfig(propsPath string, connect, firstByte, total time.Duration, flagTLS tlsutil.Files, tokenFile string) client.Config {
+	p, err := properties.Load(propsPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cfg, err := client.ConfigFromProps(p)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if connect > 0 {
+		cfg.ConnectTimeout = connect
+	}
+	if firstByte > 0 {
+		cfg.FirstByteTimeout = firstByte
+	}
+	if total > 0 {
+		cfg.Timeout = total
+	}
+	tf := tlsutil.FromProps(p).Override(flagTLS)
+	if tf.Enabled() {
+		opt, err := tf.DialOption()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		cfg.DialOptions = append(cfg.DialOptions, opt)
+	}
+	token := os.Getenv("DGREP_TOKEN")
+	if tokenFile == "" && token == "" {
+		tokenFile = p["auth.token.file"]
+	}
+	if tokenFile != "" {
+		if token, err = auth.ReadToken(tokenFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if token != "" {
+		if !tf.Enabled() {
+			fmt.Fprintln(os.Stderr, "a token is only sent over TLS; set -tls-ca or tls.ca too")
+			os.Exit(1)
+		}
+		cfg.DialOptions = append(cfg.DialOptions, auth.DialOption(token))
+	}
+	return cfg
 }
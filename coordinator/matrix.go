@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+)
+
+// matrixOutput is text output for count mode that, instead of one count
+// per host, prints a table with a row per file name and a column per
+// host, so rotated files are easy to compare across the cluster.
+type matrixOutput struct {
+	*textOutput
+	counts map[string]map[string]int64 // file -> host -> count
+}
+
+func (o *matrixOutput) count(r countRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, f := range r.Files {
+		if o.counts[f.File] == nil {
+			o.counts[f.File] = map[string]int64{}
+		}
+		o.counts[f.File][r.Host] += f.Count
+	}
+}
+
+// summary prints the table, with hosts in worker order and a TOTAL row
+// and column, then the usual summary. Cells are "-" where a host has no
+// file by that name.
+func (o *matrixOutput) summary(r summaryRecord) {
+	o.mu.Lock()
+	files := make([]string, 0, len(o.counts))
+	for f := range o.counts {
+		files = append(files, f)
+	}
+	slices.Sort(files)
+	tw := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+	fmt.Fprint(tw, "FILE\t")
+	for _, w := range r.Workers {
+		fmt.Fprintf(tw, "%s\t", w.Host)
+	}
+	fmt.Fprintln(tw, "TOTAL")
+	hostTotals := make([]int64, len(r.Workers))
+	for _, f := range files {
+		fmt.Fprintf(tw, "%s\t", f)
+		var total int64
+		for i, w := range r.Workers {
+			cell := "-"
+			if n, ok := o.counts[f][w.Host]; ok {
+				cell = strconv.FormatInt(n, 10)
+				total += n
+				hostTotals[i] += n
+			}
+			fmt.Fprintf(tw, "%s\t", cell)
+		}
+		fmt.Fprintf(tw, "%d\n", total)
+	}
+	fmt.Fprint(tw, "TOTAL\t")
+	var total int64
+	for _, n := range hostTotals {
+		fmt.Fprintf(tw, "%d\t", n)
+		total += n
+	}
+	fmt.Fprintf(tw, "%d\n", total)
+	tw.Flush()
+	o.mu.Unlock()
+	o.textOutput.summary(r)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"MP1/client"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// runMembers prints the failure detector's view of the cluster and returns
+// the exit code.
+func runMembers(c *client.Client, w io.Writer) int {
+	members, err := c.Members(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "members:", err)
+		return exitFailed
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORKER\tADDRESS\tSTATE\tINCARNATION\tGLOBS")
+	for _, m := range members {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", m.Label, m.Addr, m.State, m.Incarnation, strings.Join(m.Globs, " "))
+	}
+	tw.Flush()
+	return 0
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"MP1/client"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// writeMetrics writes a query's fan-out in the Prometheus text format to
+// path, for node_exporter's textfile collector. The file is replaced
+// atomically, so each run leaves the metrics of the latest query.
+func writeMetrics(path, mode string, outcome client.Outcome, elapsed time.Duration, sums []client.WorkerSummary) error {
+	reg := prometheus.NewRegistry()
+	query := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dgrep_coordinator_query_duration_seconds",
+		Help: "Duration of the latest query, by mode and outcome.",
+	}, []string{"mode", "outcome"})
+	finished := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "dgrep_coordinator_query_finished_timestamp_seconds",
+		Help: "When the latest query ended, in seconds since the epoch.",
+	})
+	latency := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dgrep_coordinator_worker_latency_seconds",
+		Help: "Time each worker's part of the latest query took, failover included, by worker, the worker that served it and status.",
+	}, []string{"worker", "served_by", "status"})
+	results := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dgrep_coordinator_worker_results",
+		Help: "Results each worker's shard returned in the latest query: selected lines, context lines excluded, or counted lines in count and aggregate modes.",
+	}, []string{"worker"})
+	reg.MustRegister(query, finished, latency, results)
+
+	query.WithLabelValues(mode, string(outcome)).Set(elapsed.Seconds())
+	finished.SetToCurrentTime()
+	for _, w := range sums {
+		latency.WithLabelValues(w.Label, w.ServedBy.Label, string(w.Status)).Set(w.Latency.Seconds())
+		results.WithLabelValues(w.Label).Set(float64(w.Count))
+	}
+	return prometheus.WriteToTextfile(path, reg)
+}
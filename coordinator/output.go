@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+)
+
+// lineRecord is one log line returned by a worker.
+type lineRecord struct {
+	Type   string `json:"type"` // "line"
+	Host   string `json:"host"`
+	File   string `json:"file"`
+	Line   int64  `json:"line,omitempty"`
+	Offset int64  `json:"offset"`         // byte offset of the line (of the match with -o) in the file
+	Kind   string `json:"kind"`           // "match" or "context"
+	Hunk   int64  `json:"hunk,omitempty"` // group of adjacent lines, with context lines
+	Text   string `json:"text"`
+}
+
+// countRecord is one worker's match count in count mode.
+type countRecord struct {
+	Type  string      `json:"type"` // "count"
+	Host  string      `json:"host"`
+	Count int64       `json:"count"`
+	Files []fileCount `json:"files,omitempty"` // the count broken down by file
+}
+
+// fileCount is one file's share of a countRecord.
+type fileCount struct {
+	File  string `json:"file"`
+	Count int64  `json:"count"`
+}
+
+// groupRecord is one key of an aggregation, counted across the cluster.
+type groupRecord struct {
+	Type  string `json:"type"` // "group"
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// workerSummary describes how one worker's part of the query went.
+type workerSummary struct {
+	Host      string `json:"host"`
+	Addr      string `json:"addr"`
+	Status    string `json:"status"`              // ok, unreachable, timed out, grep error or canceled
+	ServedBy  string `json:"served_by,omitempty"` // the replica holder, when it was not the worker itself
+	Count     int64  `json:"count"`               // lines received, or the count in count mode
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// summaryRecord is always the last record of a query.
+type summaryRecord struct {
+	Type      string          `json:"type"` // "summary"
+	Mode      string          `json:"mode"`
+	Outcome   string          `json:"outcome"` // complete, partial or failed
+	Total     int64           `json:"total"`
+	Other     int64           `json:"other,omitempty"` // in aggregate mode, lines whose key was dropped by a worker's group limit
+	ElapsedMS int64           `json:"elapsed_ms"`
+	Workers   []workerSummary `json:"workers"`
+}
+
+// printStatusTable writes one row per worker to w, so every query ends
+// with a record of which workers answered.
+func printStatusTable(w io.Writer, workers []workerSummary) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORKER\tADDRESS\tSTATUS\tSERVED BY\tCOUNT\tMS\tERROR")
+	for _, ws := range workers {
+		servedBy := ws.ServedBy
+		if servedBy == "" {
+			servedBy = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%s\n", ws.Host, ws.Addr, ws.Status, servedBy, ws.Count, ws.LatencyMS, ws.Error)
+	}
+	tw.Flush()
+}
+
+// output renders query results. Methods may be called from several
+// goroutines at once.
+type output interface {
+	line(lineRecord)
+	count(countRecord)
+	group(groupRecord)
+	summary(summaryRecord)
+}
+
+func newOutput(format string, w io.Writer) (output, error) {
+	switch format {
+	case "text":
+		return &textOutput{w: w, hunks: map[string]int64{}}, nil
+	case "json":
+		return &jsonOutput{w: w}, nil
+	case "ndjson":
+		return &ndjsonOutput{enc: json.NewEncoder(w)}, nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"type", "host", "file", "line", "text", "count", "latency_ms", "status", "error", "kind", "hunk", "offset"})
+		return &csvOutput{w: cw}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q (want text, json, ndjson or csv)", format)
+}
+
+// textOutput is the original human readable format. Timings go to stderr.
+type textOutput struct {
+	mu    sync.Mutex
+	w     io.Writer
+	hunks map[string]int64 // last hunk printed per host
+}
+
+// line prints r as "host:file:line:text", which can be handed to the show
+// command, or "host:file-line-text" for context lines, with "--" between
+// hunks from the same host, as grep -n does.
+func (o *textOutput) line(r lineRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if r.Hunk != 0 {
+		if last := o.hunks[r.Host]; last != 0 && last != r.Hunk {
+			fmt.Fprintln(o.w, "--")
+		}
+		o.hunks[r.Host] = r.Hunk
+	}
+	sep := ":"
+	if r.Kind == "context" {
+		sep = "-"
+	}
+	if r.Line == 0 {
+		// A per-file count from -c.
+		fmt.Fprintf(o.w, "%s:%s%s%s\n", r.Host, r.File, sep, r.Text)
+		return
+	}
+	fmt.Fprintf(o.w, "%s:%s%s%d%s%s\n", r.Host, r.File, sep, r.Line, sep, r.Text)
+}
+
+func (o *textOutput) count(r countRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fmt.Fprintf(o.w, "[%s] count=%d\n", r.Host, r.Count)
+}
+
+// group prints a key's count the way uniq -c does.
+func (o *textOutput) group(r groupRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fmt.Fprintf(o.w, "%7d %s\n", r.Count, r.Key)
+}
+
+func (o *textOutput) summary(r summaryRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, w := range r.Workers {
+		fmt.Fprintf(os.Stderr, "[%s] WORKER_MS=%d\n", w.Host, w.LatencyMS)
+	}
+	fmt.Fprintf(os.Stderr, "OVERALL_MS=%d\n", r.ElapsedMS)
+	fmt.Fprintf(os.Stderr, "OUTCOME=%s\n", r.Outcome)
+	if r.Other > 0 {
+		fmt.Fprintf(os.Stderr, "OTHER_COUNT=%d (lines not grouped: too many distinct keys)\n", r.Other)
+	}
+	if r.Mode == "count" || r.Mode == "aggregate" {
+		fmt.Fprintf(o.w, "TOTAL_COUNT=%d\n", r.Total)
+	}
+}
+
+// jsonOutput collects everything into a single JSON document written
+// once the summary is known.
+type jsonOutput struct {
+	mu      sync.Mutex
+	w       io.Writer
+	results []any
+}
+
+func (o *jsonOutput) line(r lineRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.results = append(o.results, r)
+}
+
+func (o *jsonOutput) count(r countRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.results = append(o.results, r)
+}
+
+func (o *jsonOutput) group(r groupRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.results = append(o.results, r)
+}
+
+func (o *jsonOutput) summary(r summaryRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.results == nil {
+		o.results = []any{}
+	}
+	enc := json.NewEncoder(o.w)
+	enc.SetIndent("", "  ")
+	enc.Encode(struct {
+		Results []any         `json:"results"`
+		Summary summaryRecord `json:"summary"`
+	}{o.results, r})
+}
+
+// ndjsonOutput streams one JSON object per line.
+type ndjsonOutput struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (o *ndjsonOutput) write(v any) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.enc.Encode(v)
+}
+
+func (o *ndjsonOutput) line(r lineRecord)       { o.write(r) }
+func (o *ndjsonOutput) count(r countRecord)     { o.write(r) }
+func (o *ndjsonOutput) group(r groupRecord)     { o.write(r) }
+func (o *ndjsonOutput) summary(r summaryRecord) { o.write(r) }
+
+// csvOutput writes one row per record. A count is followed by a
+// "file_count" row per file, and the summary becomes a "worker" row per
+// worker followed by a "summary" row with the total.
+type csvOutput struct {
+	mu sync.Mutex
+	w  *csv.Writer
+}
+
+func (o *csvOutput) line(r lineRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.w.Write([]string{r.Type, r.Host, r.File, strconv.FormatInt(r.Line, 10), r.Text, "", "", "", "", r.Kind, strconv.FormatInt(r.Hunk, 10), strconv.FormatInt(r.Offset, 10)})
+}
+
+func (o *csvOutput) count(r countRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.w.Write([]string{r.Type, r.Host, "", "", "", strconv.FormatInt(r.Count, 10), "", "", "", "", "", ""})
+	for _, f := range r.Files {
+		o.w.Write([]string{"file_count", r.Host, f.File, "", "", strconv.FormatInt(f.Count, 10), "", "", "", "", "", ""})
+	}
+}
+
+// group puts the key in the text column.
+func (o *csvOutput) group(r groupRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.w.Write([]string{r.Type, "", "", "", r.Key, strconv.FormatInt(r.Count, 10), "", "", "", "", "", ""})
+}
+
+func (o *csvOutput) summary(r summaryRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, w := range r.Workers {
+		o.w.Write([]string{"worker", w.Host, "", "", "", strconv.FormatInt(w.Count, 10), strconv.FormatInt(w.LatencyMS, 10), w.Status, w.Error, "", "", ""})
+	}
+	o.w.Write([]string{r.Type, "", "", "", "", strconv.FormatInt(r.Total, 10), strconv.FormatInt(r.ElapsedMS, 10), r.Outcome, "", "", "", ""})
+	o.w.Flush()
+}
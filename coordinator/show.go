@@ -0,0 +1,103 @@
+package main
+
+import (
+	"MP1/client"
+	grep "MP1/protoBuilds"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// showContext is how many lines show prints on each side of the line when
+// the spec does not say.
+const showContext = 5
+
+// showSpec is a parsed host:file:line[+-N] argument.
+type showSpec struct {
+	host, file    string
+	line          int64
+	before, after int64
+}
+
+// parseShow parses host:file:line, optionally followed by +N (N lines
+// after), -N (N lines before) or +-N (N lines on each side). The host and
+// file:line parts are what text output prints for each match.
+func parseShow(arg string) (showSpec, error) {
+	bad := fmt.Errorf("want host:file:line[+N|-N|+-N], got %q", arg)
+	host, rest, ok := strings.Cut(arg, ":")
+	i := strings.LastIndexByte(rest, ':')
+	if !ok || host == "" || i <= 0 {
+		return showSpec{}, bad
+	}
+	sp := showSpec{host: host, file: rest[:i], before: showContext, after: showContext}
+	num := rest[i+1:]
+	if j := strings.IndexAny(num, "+-"); j >= 0 {
+		suffix := num[j:]
+		num = num[:j]
+		var n int64
+		var err error
+		switch {
+		case strings.HasPrefix(suffix, "+-"):
+			n, err = strconv.ParseInt(suffix[2:], 10, 64)
+			sp.before, sp.after = n, n
+		case suffix[0] == '+':
+			n, err = strconv.ParseInt(suffix[1:], 10, 64)
+			sp.before, sp.after = 0, n
+		default:
+			n, err = strconv.ParseInt(suffix[1:], 10, 64)
+			sp.before, sp.after = n, 0
+		}
+		if err != nil || n < 0 {
+			return showSpec{}, bad
+		}
+	}
+	line, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || line < 1 {
+		return showSpec{}, bad
+	}
+	sp.line = line
+	return sp, nil
+}
+
+// runShow prints the lines of a file on a worker around the line named by
+// arg, grep -n style, and returns the exit code.
+func runShow(c *client.Client, arg string, w io.Writer) int {
+	sp, err := parseShow(arg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "show:", err)
+		return 2
+	}
+	req := &grep.ReadRangeRequest{
+		FilePath: sp.file,
+		Unit:     grep.RangeUnit_LINES,
+		Start:    max(1, sp.line-sp.before),
+		End:      sp.line + sp.after,
+	}
+	servedBy, err := c.ReadRange(context.Background(), sp.host, req, func(chunk *grep.FileChunk) error {
+		n := chunk.Line
+		for _, line := range bytes.SplitAfter(chunk.Data, []byte{'\n'}) {
+			if len(line) == 0 {
+				continue
+			}
+			sep := "-"
+			if n == sp.line {
+				sep = ":"
+			}
+			fmt.Fprintf(w, "%s:%s%s%d%s%s\n", sp.host, sp.file, sep, n, sep, bytes.TrimSuffix(line, []byte{'\n'}))
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "show:", err)
+		return exitFailed
+	}
+	if servedBy.Label != sp.host {
+		fmt.Fprintf(os.Stderr, "served by replica %s\n", servedBy.Label)
+	}
+	return 0
+}
@@ -0,0 +1,508 @@
+// Package membership is a SWIM-style failure detector. Each worker probes
+// one peer per interval, asks other peers to probe it indirectly when it
+// does not answer, and marks it suspect and then failed. Every probe and
+// answer carries the sender's view of the cluster, so what one worker
+// learns spreads to the others without extra messages.
+//
+// The seed workers from cluster.properties double as introducers: a worker
+// registers with one of them when it starts and deregisters when it stops,
+// so workers outside the seed list can join and leave.
+package membership
+
+import (
+	"MP1/properties"
+	grep "MP1/protoBuilds"
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Defaults for Config fields left zero.
+const (
+	DefaultProbeInterval  = time.Second
+	DefaultProbeTimeout   = 500 * time.Millisecond
+	DefaultSuspectTimeout = 5 * time.Second
+	DefaultIndirectProbes = 2
+)
+
+// Peer is a worker taking part in the detector.
+type Peer struct {
+	Label string
+	Addr  string
+}
+
+// Config tunes the detector. A failed worker is probed within about one
+// ProbeInterval per live member and declared failed SuspectTimeout later,
+// so raising SuspectTimeout or IndirectProbes trades detection time for
+// fewer false positives.
+type Config struct {
+	Self  Peer
+	Globs []string // log file globs Self searches, announced to the others
+	Seeds []Peer   // initial members and introducers; Self may be among them
+	// ProbeInterval is how often one member is probed.
+	ProbeInterval time.Duration
+	// ProbeTimeout bounds a direct probe, and separately the indirect ones.
+	ProbeTimeout time.Duration
+	// SuspectTimeout is how long a suspect member has to refute the
+	// suspicion before it is declared failed.
+	SuspectTimeout time.Duration
+	// IndirectProbes is how many other members are asked to probe a member
+	// that missed a direct probe.
+	IndirectProbes int
+	// DialOptions are added to the options used for every peer.
+	DialOptions []grpc.DialOption
+	// Logf reports state changes; it defaults to discarding them.
+	Logf func(format string, args ...any)
+}
+
+// ConfigFromProps reads the optional membership.probe.interval.ms,
+// membership.probe.timeout.ms, membership.suspect.timeout.ms and
+// membership.indirect.probes. Self and Seeds are left for the caller.
+func ConfigFromProps(p properties.Props) Config {
+	return Config{
+		ProbeInterval:  time.Duration(p.Int("membership.probe.interval.ms", 0)) * time.Millisecond,
+		ProbeTimeout:   time.Duration(p.Int("membership.probe.timeout.ms", 0)) * time.Millisecond,
+		SuspectTimeout: time.Duration(p.Int("membership.suspect.timeout.ms", 0)) * time.Millisecond,
+		IndirectProbes: p.Int("membership.indirect.probes", 0),
+	}
+}
+
+type member struct {
+	label, addr string
+	globs       []string
+	state       grep.MemberState
+	incarnation uint64
+	suspected   time.Time // when it became suspect
+}
+
+func (m *member) proto() *grep.Member {
+	return &grep.Member{Label: m.label, Addr: m.addr, State: m.state, Incarnation: m.incarnation, Globs: m.globs}
+}
+
+// Detector tracks which members are alive. Its Ping, PingReq and Members
+// methods serve the RPCs of the same names.
+type Detector struct {
+	cfg    Config
+	joined bool // registered with an introducer; only used by Run
+
+	mu      sync.Mutex
+	self    member
+	members map[string]*member // by label, self excluded
+	order   []string           // probe order, reshuffled each round
+	next    int
+	conns   map[string]*grpc.ClientConn // by address
+
+	// now and dial are time.Now and connect, replaced in tests.
+	now  func() time.Time
+	dial func(addr string) (grep.GrepServiceClient, error)
+}
+
+// New returns a detector for cfg. Call Run to start probing.
+func New(cfg Config) *Detector {
+	if cfg.ProbeInterval <= 0 {
+		cfg.ProbeInterval = DefaultProbeInterval
+	}
+	if cfg.ProbeTimeout <= 0 {
+		cfg.ProbeTimeout = DefaultProbeTimeout
+	}
+	if cfg.SuspectTimeout <= 0 {
+		cfg.SuspectTimeout = DefaultSuspectTimeout
+	}
+	if cfg.IndirectProbes <= 0 {
+		cfg.IndirectProbes = DefaultIndirectProbes
+	}
+	if cfg.Logf == nil {
+		cfg.Logf = func(string, ...any) {}
+	}
+	d := &Detector{
+		cfg: cfg,
+		// Starting from the clock means a restarted worker's incarnation
+		// beats whatever the cluster remembers about its previous life.
+		self:    member{label: cfg.Self.Label, addr: cfg.Self.Addr, globs: cfg.Globs, incarnation: uint64(time.Now().UnixNano())},
+		members: map[string]*member{},
+		conns:   map[string]*grpc.ClientConn{},
+		now:     time.Now,
+	}
+	d.dial = d.connect
+	for _, p := range cfg.Seeds {
+		if p.Label != cfg.Self.Label {
+			d.members[p.Label] = &member{label: p.Label, addr: p.Addr}
+		}
+	}
+	return d
+}
+
+// Run registers with an introducer and probes members until ctx is done.
+func (d *Detector) Run(ctx context.Context) {
+	t := time.NewTicker(d.cfg.ProbeInterval)
+	defer t.Stop()
+	defer d.closeConns()
+	for {
+		if !d.joined {
+			// The first seed up has nobody to register with; it keeps
+			// trying so the others learn its globs.
+			d.joined = d.register(ctx)
+		}
+		d.expireSuspects()
+		if target, ok := d.nextTarget(); ok {
+			d.probe(ctx, target)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Members returns every known member, this worker first.
+func (d *Detector) Members() []*grep.Member {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.snapshot()
+}
+
+// Ping answers a direct probe.
+func (d *Detector) Ping(_ context.Context, req *grep.PingRequest) (*grep.PingAck, error) {
+	d.merge(req.Updates)
+	return &grep.PingAck{Updates: d.Members()}, nil
+}
+
+// PingReq probes req.Target on behalf of another member. The target must
+// already be a member here, so callers cannot have this worker dial any
+// address they like; it is checked before req.Updates are merged, which
+// could otherwise add it.
+func (d *Detector) PingReq(ctx context.Context, req *grep.PingReqRequest) (*grep.PingAck, error) {
+	if !d.isMember(req.Target) {
+		return nil, status.Errorf(codes.PermissionDenied, "ping-req: %q is not a member", req.Target)
+	}
+	d.merge(req.Updates)
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.ProbeTimeout)
+	defer cancel()
+	if err := d.ping(ctx, req.Target); err != nil {
+		return nil, err
+	}
+	return &grep.PingAck{Updates: d.Members()}, nil
+}
+
+// isMember reports whether addr is the address of a known member.
+func (d *Detector) isMember(addr string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, m := range d.members {
+		if m.addr == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Register adds a worker announcing itself, and returns the cluster as
+// this worker sees it.
+func (d *Detector) Register(_ context.Context, req *grep.RegisterRequest) (*grep.RegisterResponse, error) {
+	if req.Member.GetLabel() == "" || req.Member.GetAddr() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "register: label and addr are required")
+	}
+	m := proto.Clone(req.Member).(*grep.Member)
+	m.State = grep.MemberState_ALIVE
+	d.merge([]*grep.Member{m})
+	return &grep.RegisterResponse{Members: d.Members()}, nil
+}
+
+// Deregister records that a worker has left.
+func (d *Detector) Deregister(_ context.Context, req *grep.DeregisterRequest) (*grep.DeregisterResponse, error) {
+	if req.Member.GetState() != grep.MemberState_LEFT {
+		return nil, status.Errorf(codes.InvalidArgument, "deregister: member must be in state LEFT")
+	}
+	d.merge([]*grep.Member{req.Member})
+	return &grep.DeregisterResponse{}, nil
+}
+
+// register announces this worker to the first introducer that answers.
+func (d *Detector) register(ctx context.Context) bool {
+	for _, p := range d.cfg.Seeds {
+		if p.Label == d.cfg.Self.Label {
+			continue
+		}
+		cli, err := d.dial(p.Addr)
+		if err != nil {
+			continue
+		}
+		rctx, cancel := context.WithTimeout(ctx, d.cfg.ProbeTimeout)
+		resp, err := cli.Register(rctx, &grep.RegisterRequest{Member: d.selfProto()})
+		cancel()
+		if err == nil {
+			d.cfg.Logf("registered with %s", p.Label)
+			d.merge(resp.Members)
+			return true
+		}
+	}
+	return false
+}
+
+// Leave deregisters this worker, telling every live member it knows so
+// none of them has to detect the departure as a failure.
+func (d *Detector) Leave(ctx context.Context) {
+	d.mu.Lock()
+	d.self.state = grep.MemberState_LEFT
+	d.self.incarnation++
+	var addrs []string
+	for _, m := range d.members {
+		if m.state == grep.MemberState_ALIVE || m.state == grep.MemberState_SUSPECT {
+			addrs = append(addrs, m.addr)
+		}
+	}
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.ProbeTimeout)
+	defer cancel()
+	req := &grep.DeregisterRequest{Member: d.selfProto()}
+	var wg sync.WaitGroup
+	for _, addr := range addrs {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			if cli, err := d.dial(addr); err == nil {
+				cli.Deregister(ctx, req)
+			}
+		}(addr)
+	}
+	wg.Wait()
+}
+
+func (d *Detector) selfProto() *grep.Member {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.self.proto()
+}
+
+// snapshot copies the member list. d.mu must be held.
+func (d *Detector) snapshot() []*grep.Member {
+	out := []*grep.Member{d.self.proto()}
+	labels := make([]string, 0, len(d.members))
+	for l := range d.members {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		out = append(out, d.members[l].proto())
+	}
+	return out
+}
+
+// rank orders states for updates with equal incarnations.
+func rank(s grep.MemberState) int {
+	switch s {
+	case grep.MemberState_SUSPECT:
+		return 1
+	case grep.MemberState_FAILED:
+		return 2
+	case grep.MemberState_LEFT:
+		return 3
+	}
+	return 0
+}
+
+// merge applies another member's view. An entry replaces ours if it has a
+// higher incarnation, or the same incarnation and a worse state; only a
+// member itself raises its incarnation, which is how it refutes being
+// suspected or comes back after a restart.
+func (d *Detector) merge(updates []*grep.Member) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, u := range updates {
+		if u.Label == "" {
+			continue
+		}
+		if u.Label == d.self.label {
+			if d.self.state == grep.MemberState_ALIVE && u.State != grep.MemberState_ALIVE && u.Incarnation >= d.self.incarnation {
+				d.self.incarnation = u.Incarnation + 1
+				d.cfg.Logf("refuting %s with incarnation %d", u.State, d.self.incarnation)
+			}
+			continue
+		}
+		m, ok := d.members[u.Label]
+		if !ok {
+			m = &member{label: u.Label, addr: u.Addr, globs: u.Globs, state: u.State, incarnation: u.Incarnation, suspected: d.now()}
+			d.members[u.Label] = m
+			d.cfg.Logf("member %s (%s) joined as %s", u.Label, u.Addr, u.State)
+			continue
+		}
+		if u.Incarnation < m.incarnation || (u.Incarnation == m.incarnation && rank(u.State) <= rank(m.state)) {
+			continue
+		}
+		d.setState(m, u.State, u.Incarnation)
+		if u.Addr != "" {
+			m.addr = u.Addr
+		}
+		if len(u.Globs) > 0 {
+			m.globs = u.Globs
+		}
+	}
+}
+
+// setState records a state change. d.mu must be held.
+func (d *Detector) setState(m *member, st grep.MemberState, inc uint64) {
+	if m.state != st {
+		d.cfg.Logf("member %s is %s", m.label, st)
+	}
+	if st == grep.MemberState_SUSPECT && m.state != st {
+		m.suspected = d.now()
+	}
+	m.state, m.incarnation = st, inc
+}
+
+// expireSuspects declares failed the members suspected for too long.
+func (d *Detector) expireSuspects() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, m := range d.members {
+		if m.state == grep.MemberState_SUSPECT && d.now().Sub(m.suspected) >= d.cfg.SuspectTimeout {
+			d.setState(m, grep.MemberState_FAILED, m.incarnation)
+		}
+	}
+}
+
+// nextTarget picks the next member to probe, going round the members in
+// a random order that changes every round. Failed and departed members
+// are skipped; they rejoin by registering or probing us with a higher
+// incarnation.
+func (d *Detector) nextTarget() (member, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for tries := 0; tries <= len(d.members); tries++ {
+		if d.next >= len(d.order) {
+			d.order = d.order[:0]
+			for l := range d.members {
+				d.order = append(d.order, l)
+			}
+			rand.Shuffle(len(d.order), func(i, j int) { d.order[i], d.order[j] = d.order[j], d.order[i] })
+			d.next = 0
+		}
+		if len(d.order) == 0 {
+			return member{}, false
+		}
+		m, ok := d.members[d.order[d.next]]
+		d.next++
+		if ok && (m.state == grep.MemberState_ALIVE || m.state == grep.MemberState_SUSPECT) {
+			return *m, true
+		}
+	}
+	return member{}, false
+}
+
+// probe pings target directly, then through other members, and suspects it
+// if nobody reaches it.
+func (d *Detector) probe(ctx context.Context, target member) {
+	pctx, cancel := context.WithTimeout(ctx, d.cfg.ProbeTimeout)
+	err := d.ping(pctx, target.addr)
+	cancel()
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+
+	helpers := d.helpers(target.label)
+	acks := make(chan bool, len(helpers))
+	for _, h := range helpers {
+		go func(h string) {
+			// The helper spends up to ProbeTimeout on the target, so allow
+			// it that much again to answer.
+			ictx, cancel := context.WithTimeout(ctx, 2*d.cfg.ProbeTimeout)
+			defer cancel()
+			cli, err := d.dial(h)
+			var ack *grep.PingAck
+			if err == nil {
+				ack, err = cli.PingReq(ictx, &grep.PingReqRequest{From: d.cfg.Self.Label, Target: target.addr, Updates: d.Members()})
+			}
+			if err == nil {
+				d.merge(ack.Updates)
+			}
+			acks <- err == nil
+		}(h)
+	}
+	for range helpers {
+		if <-acks {
+			return
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// Leave it alone if it refuted or rejoined while we were probing.
+	if m, ok := d.members[target.label]; ok && m.state == grep.MemberState_ALIVE && m.incarnation == target.incarnation {
+		d.cfg.Logf("no answer from %s: %v", target.label, err)
+		d.setState(m, grep.MemberState_SUSPECT, m.incarnation)
+	}
+}
+
+// helpers picks up to IndirectProbes live members other than label.
+func (d *Detector) helpers(label string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var addrs []string
+	for _, m := range d.members {
+		if m.label != label && m.state == grep.MemberState_ALIVE {
+			addrs = append(addrs, m.addr)
+		}
+	}
+	rand.Shuffle(len(addrs), func(i, j int) { addrs[i], addrs[j] = addrs[j], addrs[i] })
+	return addrs[:min(len(addrs), d.cfg.IndirectProbes)]
+}
+
+func (d *Detector) ping(ctx context.Context, addr string) error {
+	cli, err := d.dial(addr)
+	var ack *grep.PingAck
+	if err == nil {
+		ack, err = cli.Ping(ctx, &grep.PingRequest{From: d.cfg.Self.Label, Updates: d.Members()})
+	}
+	if err != nil {
+		return fmt.Errorf("ping %s: %w", addr, err)
+	}
+	d.merge(ack.Updates)
+	return nil
+}
+
+// connect returns a client for addr, reusing its connection across probes.
+func (d *Detector) connect(addr string) (grep.GrepServiceClient, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	conn, ok := d.conns[addr]
+	if !ok {
+		// Reconnect at least once per interval, so a member that comes
+		// back is not taken for dead while its connection backs off.
+		bo := backoff.DefaultConfig
+		bo.MaxDelay = d.cfg.ProbeInterval
+		opts := append([]grpc.DialOption{
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithConnectParams(grpc.ConnectParams{Backoff: bo, MinConnectTimeout: d.cfg.ProbeTimeout}),
+		}, d.cfg.DialOptions...)
+		var err error
+		if conn, err = grpc.NewClient(addr, opts...); err != nil {
+			return nil, err
+		}
+		d.conns[addr] = conn
+	}
+	return grep.NewGrepServiceClient(conn), nil
+}
+
+func (d *Detector) closeConns() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for addr, c := range d.conns {
+		c.Close()
+		delete(d.conns, addr)
+	}
+}
@@ -0,0 +1,353 @@
+package membership
+
+import (
+	grep "MP1/protoBuilds"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeClock is a clock that only moves when told to.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+// fakeNet connects detectors by calling each other's methods directly.
+type fakeNet struct {
+	mu     sync.Mutex
+	nodes  map[string]*Detector // by address
+	cut    map[[2]string]bool   // links that drop calls, from -> to
+	dialed []string             // every address dialed, in order
+}
+
+func newFakeNet() *fakeNet {
+	return &fakeNet{nodes: map[string]*Detector{}, cut: map[[2]string]bool{}}
+}
+
+// node adds a detector for label, at address label, to the network.
+func (n *fakeNet) node(t *testing.T, clock *fakeClock, label string, seeds ...string) *Detector {
+	t.Helper()
+	cfg := Config{Self: Peer{Label: label, Addr: label}, Logf: t.Logf}
+	for _, s := range seeds {
+		cfg.Seeds = append(cfg.Seeds, Peer{Label: s, Addr: s})
+	}
+	d := New(cfg)
+	d.now = clock.now
+	d.dial = func(addr string) (grep.GrepServiceClient, error) {
+		n.mu.Lock()
+		n.dialed = append(n.dialed, addr)
+		n.mu.Unlock()
+		return fakeClient{net: n, from: label, to: addr}, nil
+	}
+	n.mu.Lock()
+	n.nodes[label] = d
+	n.mu.Unlock()
+	return d
+}
+
+// reach returns the detector at to, unless it is missing or the link from
+// from is cut.
+func (n *fakeNet) reach(from, to string) (*Detector, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	d, ok := n.nodes[to]
+	if !ok || n.cut[[2]string{from, to}] {
+		return nil, status.Errorf(codes.Unavailable, "%s cannot reach %s", from, to)
+	}
+	return d, nil
+}
+
+// fakeClient is the prober's view of one peer on a fakeNet.
+type fakeClient struct {
+	grep.GrepServiceClient
+	net      *fakeNet
+	from, to string
+}
+
+func (c fakeClient) Ping(ctx context.Context, req *grep.PingRequest, _ ...grpc.CallOption) (*grep.PingAck, error) {
+	d, err := c.net.reach(c.from, c.to)
+	if err != nil {
+		return nil, err
+	}
+	return d.Ping(ctx, req)
+}
+
+func (c fakeClient) PingReq(ctx context.Context, req *grep.PingReqRequest, _ ...grpc.CallOption) (*grep.PingAck, error) {
+	d, err := c.net.reach(c.from, c.to)
+	if err != nil {
+		return nil, err
+	}
+	return d.PingReq(ctx, req)
+}
+
+func (c fakeClient) Register(ctx context.Context, req *grep.RegisterRequest, _ ...grpc.CallOption) (*grep.RegisterResponse, error) {
+	d, err := c.net.reach(c.from, c.to)
+	if err != nil {
+		return nil, err
+	}
+	return d.Register(ctx, req)
+}
+
+func (c fakeClient) Deregister(ctx context.Context, req *grep.DeregisterRequest, _ ...grpc.CallOption) (*grep.DeregisterResponse, error) {
+	d, err := c.net.reach(c.from, c.to)
+	if err != nil {
+		return nil, err
+	}
+	return d.Deregister(ctx, req)
+}
+
+// state returns what d thinks of label.
+func state(t *testing.T, d *Detector, label string) (grep.MemberState, uint64) {
+	t.Helper()
+	for _, m := range d.Members() {
+		if m.Label == label {
+			return m.State, m.Incarnation
+		}
+	}
+	t.Fatalf("%s does not know %s", d.cfg.Self.Label, label)
+	return 0, 0
+}
+
+const (
+	alive   = grep.MemberState_ALIVE
+	suspect = grep.MemberState_SUSPECT
+	failed  = grep.MemberState_FAILED
+	left    = grep.MemberState_LEFT
+)
+
+func TestMergePrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		was     grep.MemberState
+		update  grep.MemberState
+		inc     uint64 // of the update; the member is at 5
+		want    grep.MemberState
+		wantInc uint64
+	}{
+		{"older suspicion", alive, suspect, 4, alive, 5},
+		{"suspicion", alive, suspect, 5, suspect, 5},
+		{"stale alive", suspect, alive, 5, suspect, 5},
+		{"refutation", suspect, alive, 6, alive, 6},
+		{"failure", suspect, failed, 5, failed, 5},
+		{"suspicion after failure", failed, suspect, 5, failed, 5},
+		{"rejoin", failed, alive, 6, alive, 6},
+		{"older rejoin", failed, alive, 4, failed, 5},
+		{"leave", alive, left, 5, left, 5},
+		{"alive after leaving", left, alive, 5, left, 5},
+		{"failure after leaving", left, failed, 5, left, 5},
+		{"return after leaving", left, alive, 6, alive, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newFakeNet().node(t, &fakeClock{}, "a", "b")
+			d.members["b"].state, d.members["b"].incarnation = tt.was, 5
+			d.merge([]*grep.Member{{Label: "b", Addr: "b", State: tt.update, Incarnation: tt.inc}})
+			if st, inc := state(t, d, "b"); st != tt.want || inc != tt.wantInc {
+				t.Fatalf("b is %s at %d, want %s at %d", st, inc, tt.want, tt.wantInc)
+			}
+		})
+	}
+}
+
+func TestMergeAddsNewMembers(t *testing.T) {
+	d := newFakeNet().node(t, &fakeClock{}, "a")
+	d.merge([]*grep.Member{{Label: "b", Addr: "b:6000", State: suspect, Incarnation: 3, Globs: []string{"*.log"}}})
+	if st, inc := state(t, d, "b"); st != suspect || inc != 3 {
+		t.Fatalf("b is %s at %d, want SUSPECT at 3", st, inc)
+	}
+	if m := d.members["b"]; m.addr != "b:6000" || len(m.globs) != 1 {
+		t.Fatalf("b = %+v, want its address and globs", m)
+	}
+}
+
+func TestRefutesSuspicion(t *testing.T) {
+	net := newFakeNet()
+	clock := &fakeClock{}
+	a := net.node(t, clock, "a", "b")
+	b := net.node(t, clock, "b", "a")
+	_, inc := state(t, b, "b")
+
+	// a suspects b and says so in its next probe.
+	a.members["b"].state, a.members["b"].incarnation = suspect, inc
+	if err := a.ping(context.Background(), "b"); err != nil {
+		t.Fatal(err)
+	}
+	if st, got := state(t, b, "b"); st != alive || got != inc+1 {
+		t.Fatalf("b sees itself %s at %d, want ALIVE at %d", st, got, inc+1)
+	}
+	// b's answer carried the refutation back.
+	if st, got := state(t, a, "b"); st != alive || got != inc+1 {
+		t.Fatalf("a sees b %s at %d, want ALIVE at %d", st, got, inc+1)
+	}
+
+	// Being reported alive needs no refutation.
+	b.merge([]*grep.Member{{Label: "b", State: alive, Incarnation: inc + 5}})
+	if _, got := state(t, b, "b"); got != inc+1 {
+		t.Fatalf("b's incarnation = %d after an ALIVE report, want %d", got, inc+1)
+	}
+}
+
+func TestExpireSuspects(t *testing.T) {
+	net := newFakeNet()
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	a := net.node(t, clock, "a", "b", "c")
+	net.node(t, clock, "c", "a", "b")
+	// b never started, so neither a nor its helper c reaches it.
+	a.probe(context.Background(), *a.members["b"])
+	if st, _ := state(t, a, "b"); st != suspect {
+		t.Fatalf("b is %s after a missed probe, want SUSPECT", st)
+	}
+
+	clock.advance(a.cfg.SuspectTimeout - time.Millisecond)
+	a.expireSuspects()
+	if st, _ := state(t, a, "b"); st != suspect {
+		t.Fatalf("b is %s before the suspect timeout, want SUSPECT", st)
+	}
+	clock.advance(time.Millisecond)
+	a.expireSuspects()
+	if st, _ := state(t, a, "b"); st != failed {
+		t.Fatalf("b is %s after the suspect timeout, want FAILED", st)
+	}
+}
+
+func TestNextTargetRoundRobin(t *testing.T) {
+	d := newFakeNet().node(t, &fakeClock{}, "a", "b", "c", "d", "e")
+	d.members["c"].state = suspect
+	d.members["e"].state = failed
+	for round := range 3 {
+		seen := map[string]int{}
+		for range 3 {
+			m, ok := d.nextTarget()
+			if !ok {
+				t.Fatalf("round %d: no target", round)
+			}
+			seen[m.label]++
+		}
+		if len(seen) != 3 || seen["b"] != 1 || seen["c"] != 1 || seen["d"] != 1 {
+			t.Fatalf("round %d probed %v, want b, c and d once each", round, seen)
+		}
+	}
+
+	for _, l := range []string{"b", "c", "d"} {
+		d.members[l].state = failed
+	}
+	if m, ok := d.nextTarget(); ok {
+		t.Fatalf("nextTarget = %s with every member failed, want none", m.label)
+	}
+}
+
+func TestPingReqRelays(t *testing.T) {
+	net := newFakeNet()
+	clock := &fakeClock{}
+	a := net.node(t, clock, "a", "b", "c")
+	net.node(t, clock, "b", "a", "c")
+	net.node(t, clock, "c", "a", "b")
+
+	// a cannot reach c itself, but b can on its behalf.
+	net.cut[[2]string{"a", "c"}] = true
+	a.probe(context.Background(), *a.members["c"])
+	if st, _ := state(t, a, "c"); st != alive {
+		t.Fatalf("c is %s after an indirect ack, want ALIVE", st)
+	}
+
+	// With c down, b's relayed probe fails too.
+	delete(net.nodes, "c")
+	a.probe(context.Background(), *a.members["c"])
+	if st, _ := state(t, a, "c"); st != suspect {
+		t.Fatalf("c is %s with nobody reaching it, want SUSPECT", st)
+	}
+}
+
+func TestPingReqOnlyRelaysToMembers(t *testing.T) {
+	net := newFakeNet()
+	b := net.node(t, &fakeClock{}, "b", "a")
+	net.dialed = nil
+	// The target is smuggled in as an update, too.
+	_, err := b.PingReq(context.Background(), &grep.PingReqRequest{
+		From:    "a",
+		Target:  "evil:25",
+		Updates: []*grep.Member{{Label: "evil", Addr: "evil:25"}},
+	})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("PingReq to a non-member: %v, want PermissionDenied", err)
+	}
+	if len(net.dialed) != 0 {
+		t.Fatalf("PingReq to a non-member dialed %v", net.dialed)
+	}
+}
+
+func TestRegisterJoinsThroughIntroducer(t *testing.T) {
+	net := newFakeNet()
+	clock := &fakeClock{}
+	a := net.node(t, clock, "a", "a", "b")
+	net.node(t, clock, "b", "a", "b")
+	// x is not a seed; it only knows the introducers.
+	x := net.node(t, clock, "x", "a", "b")
+	x.cfg.Globs = []string{"app.log"}
+	x.self.globs = x.cfg.Globs
+
+	if !x.register(context.Background()) {
+		t.Fatal("register found no introducer")
+	}
+	if st, _ := state(t, a, "x"); st != alive {
+		t.Fatalf("introducer sees x as %s, want ALIVE", st)
+	}
+	if m := a.members["x"]; m.addr != "x" || len(m.globs) != 1 || m.globs[0] != "app.log" {
+		t.Fatalf("introducer has x as %+v, want its address and globs", m)
+	}
+	// x learned the cluster from the introducer's answer.
+	if st, _ := state(t, x, "b"); st != alive {
+		t.Fatalf("x sees b as %s, want ALIVE", st)
+	}
+
+	if _, err := a.Register(context.Background(), &grep.RegisterRequest{Member: &grep.Member{Label: "y"}}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Register without an address: %v, want InvalidArgument", err)
+	}
+}
+
+func TestLeftMembersAreNotProbed(t *testing.T) {
+	net := newFakeNet()
+	clock := &fakeClock{}
+	a := net.node(t, clock, "a", "b", "c")
+	b := net.node(t, clock, "b", "a", "c")
+	net.node(t, clock, "c", "a", "b")
+
+	b.Leave(context.Background())
+	_, inc := state(t, b, "b")
+	if st, got := state(t, a, "b"); st != left || got != inc {
+		t.Fatalf("a sees b as %s at %d after it left, want LEFT at %d", st, got, inc)
+	}
+	// A stale report of b as alive does not bring it back.
+	a.merge([]*grep.Member{{Label: "b", State: alive, Incarnation: inc}})
+	if st, _ := state(t, a, "b"); st != left {
+		t.Fatalf("a sees b as %s after a stale ALIVE, want LEFT", st)
+	}
+
+	for range 4 {
+		m, ok := a.nextTarget()
+		if !ok || m.label != "c" {
+			t.Fatalf("nextTarget = %s, %v; want only c", m.label, ok)
+		}
+	}
+	if h := a.helpers("c"); len(h) != 0 {
+		t.Fatalf("helpers for c = %v, want none once b left", h)
+	}
+}
@@ -9,6 +9,7 @@ package grep
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,10 +22,259 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type GroupBy int32
+
+const (
+	GroupBy_CAPTURE GroupBy = 0 // a capture group of the patterns
+	GroupBy_FIELD   GroupBy = 1 // a field of the line
+)
+
+// Enum value maps for GroupBy.
+var (
+	GroupBy_name = map[int32]string{
+		0: "CAPTURE",
+		1: "FIELD",
+	}
+	GroupBy_value = map[string]int32{
+		"CAPTURE": 0,
+		"FIELD":   1,
+	}
+)
+
+func (x GroupBy) Enum() *GroupBy {
+	p := new(GroupBy)
+	*p = x
+	return p
+}
+
+func (x GroupBy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GroupBy) Descriptor() protoreflect.EnumDescriptor {
+	return file_grep_proto_enumTypes[0].Descriptor()
+}
+
+func (GroupBy) Type() protoreflect.EnumType {
+	return &file_grep_proto_enumTypes[0]
+}
+
+func (x GroupBy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GroupBy.Descriptor instead.
+func (GroupBy) EnumDescriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{0}
+}
+
+type PatternSyntax int32
+
+const (
+	PatternSyntax_BASIC    PatternSyntax = 0 // POSIX basic regexp, grep's default
+	PatternSyntax_FIXED    PatternSyntax = 1 // literal strings, grep -F
+	PatternSyntax_EXTENDED PatternSyntax = 2 // POSIX extended regexp, grep -E
+	PatternSyntax_PCRE     PatternSyntax = 3 // Perl-style regexp as supported by Go (RE2), grep -P
+)
+
+// Enum value maps for PatternSyntax.
+var (
+	PatternSyntax_name = map[int32]string{
+		0: "BASIC",
+		1: "FIXED",
+		2: "EXTENDED",
+		3: "PCRE",
+	}
+	PatternSyntax_value = map[string]int32{
+		"BASIC":    0,
+		"FIXED":    1,
+		"EXTENDED": 2,
+		"PCRE":     3,
+	}
+)
+
+func (x PatternSyntax) Enum() *PatternSyntax {
+	p := new(PatternSyntax)
+	*p = x
+	return p
+}
+
+func (x PatternSyntax) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PatternSyntax) Descriptor() protoreflect.EnumDescriptor {
+	return file_grep_proto_enumTypes[1].Descriptor()
+}
+
+func (PatternSyntax) Type() protoreflect.EnumType {
+	return &file_grep_proto_enumTypes[1]
+}
+
+func (x PatternSyntax) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PatternSyntax.Descriptor instead.
+func (PatternSyntax) EnumDescriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{1}
+}
+
+type LineKind int32
+
+const (
+	LineKind_MATCH   LineKind = 0 // a selected line, or the part of it that matched with -o
+	LineKind_CONTEXT LineKind = 1 // a -A/-B/-C line around a selected one
+)
+
+// Enum value maps for LineKind.
+var (
+	LineKind_name = map[int32]string{
+		0: "MATCH",
+		1: "CONTEXT",
+	}
+	LineKind_value = map[string]int32{
+		"MATCH":   0,
+		"CONTEXT": 1,
+	}
+)
+
+func (x LineKind) Enum() *LineKind {
+	p := new(LineKind)
+	*p = x
+	return p
+}
+
+func (x LineKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LineKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_grep_proto_enumTypes[2].Descriptor()
+}
+
+func (LineKind) Type() protoreflect.EnumType {
+	return &file_grep_proto_enumTypes[2]
+}
+
+func (x LineKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LineKind.Descriptor instead.
+func (LineKind) EnumDescriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{2}
+}
+
+type RangeUnit int32
+
+const (
+	RangeUnit_LINES RangeUnit = 0
+	RangeUnit_BYTES RangeUnit = 1
+)
+
+// Enum value maps for RangeUnit.
+var (
+	RangeUnit_name = map[int32]string{
+		0: "LINES",
+		1: "BYTES",
+	}
+	RangeUnit_value = map[string]int32{
+		"LINES": 0,
+		"BYTES": 1,
+	}
+)
+
+func (x RangeUnit) Enum() *RangeUnit {
+	p := new(RangeUnit)
+	*p = x
+	return p
+}
+
+func (x RangeUnit) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RangeUnit) Descriptor() protoreflect.EnumDescriptor {
+	return file_grep_proto_enumTypes[3].Descriptor()
+}
+
+func (RangeUnit) Type() protoreflect.EnumType {
+	return &file_grep_proto_enumTypes[3]
+}
+
+func (x RangeUnit) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RangeUnit.Descriptor instead.
+func (RangeUnit) EnumDescriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{3}
+}
+
+type MemberState int32
+
+const (
+	MemberState_ALIVE   MemberState = 0
+	MemberState_SUSPECT MemberState = 1 // missed a probe; failed unless it answers in time
+	MemberState_FAILED  MemberState = 2
+	MemberState_LEFT    MemberState = 3 // deregistered
+)
+
+// Enum value maps for MemberState.
+var (
+	MemberState_name = map[int32]string{
+		0: "ALIVE",
+		1: "SUSPECT",
+		2: "FAILED",
+		3: "LEFT",
+	}
+	MemberState_value = map[string]int32{
+		"ALIVE":   0,
+		"SUSPECT": 1,
+		"FAILED":  2,
+		"LEFT":    3,
+	}
+)
+
+func (x MemberState) Enum() *MemberState {
+	p := new(MemberState)
+	*p = x
+	return p
+}
+
+func (x MemberState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MemberState) Descriptor() protoreflect.EnumDescriptor {
+	return file_grep_proto_enumTypes[4].Descriptor()
+}
+
+func (MemberState) Type() protoreflect.EnumType {
+	return &file_grep_proto_enumTypes[4]
+}
+
+func (x MemberState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MemberState.Descriptor instead.
+func (MemberState) EnumDescriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{4}
+}
+
 type SearchRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	GrepOptions   []string               `protobuf:"bytes,1,rep,name=grepOptions,proto3" json:"grepOptions,omitempty"` // passed to grep as-is
-	Mode          string                 `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`               // "lines" or "count"
+	GrepOptions   []string               `protobuf:"bytes,1,rep,name=grepOptions,proto3" json:"grepOptions,omitempty"` // legacy; only allowlisted grep options are accepted
+	Mode          string                 `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`               // "lines", "count" or "aggregate"
+	Query         *Query                 `protobuf:"bytes,3,opt,name=query,proto3" json:"query,omitempty"`             // typed search, preferred over grepOptions
+	Since         *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=since,proto3" json:"since,omitempty"`             // only lines stamped at or after this time
+	Until         *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=until,proto3" json:"until,omitempty"`             // only lines stamped at or before this time
+	Shard         string                 `protobuf:"bytes,6,opt,name=shard,proto3" json:"shard,omitempty"`             // primary whose logs to search, from this worker's replicas; empty for its own logs
+	Follow        bool                   `protobuf:"varint,7,opt,name=follow,proto3" json:"follow,omitempty"`          // keep the stream open and send matching lines as they are appended, like tail -F | grep
+	MaxResults    int64                  `protobuf:"varint,8,opt,name=maxResults,proto3" json:"maxResults,omitempty"`  // in lines mode, stop after sending this many selected (non-context) lines in all; 0 for no limit
+	Aggregate     *Aggregation           `protobuf:"bytes,9,opt,name=aggregate,proto3" json:"aggregate,omitempty"`     // when mode=="aggregate", what to count selected lines by
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -73,30 +323,80 @@ func (x *SearchRequest) GetMode() string {
 	return ""
 }
 
-type SearchResponse struct {
+func (x *SearchRequest) GetQuery() *Query {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetShard() string {
+	if x != nil {
+		return x.Shard
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+func (x *SearchRequest) GetMaxResults() int64 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetAggregate() *Aggregation {
+	if x != nil {
+		return x.Aggregate
+	}
+	return nil
+}
+
+type Aggregation struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Host          string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`         // worker label
-	FilePath      string                 `protobuf:"bytes,2,opt,name=filePath,proto3" json:"filePath,omitempty"` // when mode=="lines"
-	Log           string                 `protobuf:"bytes,3,opt,name=log,proto3" json:"log,omitempty"`           // when mode=="lines"
-	Count         int64                  `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`      // when mode=="count", sum across files on worker
+	By            GroupBy                `protobuf:"varint,1,opt,name=by,proto3,enum=grep.GroupBy" json:"by,omitempty"`
+	Index         int32                  `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`         // CAPTURE: group number, 0 for the whole match, numbered in order across patterns; FIELD: field number from 1, 0 for the whole line
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`            // CAPTURE: named group, used instead of index when set
+	Separator     string                 `protobuf:"bytes,4,opt,name=separator,proto3" json:"separator,omitempty"`  // FIELD: separator; empty splits on runs of blanks
+	MaxGroups     int32                  `protobuf:"varint,5,opt,name=maxGroups,proto3" json:"maxGroups,omitempty"` // keys each worker keeps; past it a new key replaces the least frequent, whose lines count as other; 0 for the worker's limit
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SearchResponse) Reset() {
-	*x = SearchResponse{}
+func (x *Aggregation) Reset() {
+	*x = Aggregation{}
 	mi := &file_grep_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SearchResponse) String() string {
+func (x *Aggregation) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SearchResponse) ProtoMessage() {}
+func (*Aggregation) ProtoMessage() {}
 
-func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+func (x *Aggregation) ProtoReflect() protoreflect.Message {
 	mi := &file_grep_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -108,55 +408,1911 @@ func (x *SearchResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
-func (*SearchResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use Aggregation.ProtoReflect.Descriptor instead.
+func (*Aggregation) Descriptor() ([]byte, []int) {
 	return file_grep_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *SearchResponse) GetHost() string {
+func (x *Aggregation) GetBy() GroupBy {
 	if x != nil {
-		return x.Host
+		return x.By
 	}
-	return ""
+	return GroupBy_CAPTURE
 }
 
-func (x *SearchResponse) GetFilePath() string {
+func (x *Aggregation) GetIndex() int32 {
 	if x != nil {
-		return x.FilePath
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Aggregation) GetName() string {
+	if x != nil {
+		return x.Name
 	}
 	return ""
 }
 
-func (x *SearchResponse) GetLog() string {
+func (x *Aggregation) GetSeparator() string {
 	if x != nil {
-		return x.Log
+		return x.Separator
 	}
 	return ""
 }
 
-func (x *SearchResponse) GetCount() int64 {
+func (x *Aggregation) GetMaxGroups() int32 {
 	if x != nil {
-		return x.Count
+		return x.MaxGroups
 	}
 	return 0
 }
 
-var File_grep_proto protoreflect.FileDescriptor
+type Query struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Patterns      []string               `protobuf:"bytes,1,rep,name=patterns,proto3" json:"patterns,omitempty"` // a line is selected if any pattern matches
+	Syntax        PatternSyntax          `protobuf:"varint,2,opt,name=syntax,proto3,enum=grep.PatternSyntax" json:"syntax,omitempty"`
+	IgnoreCase    bool                   `protobuf:"varint,3,opt,name=ignoreCase,proto3" json:"ignoreCase,omitempty"`
+	Invert        bool                   `protobuf:"varint,4,opt,name=invert,proto3" json:"invert,omitempty"`
+	WholeWord     bool                   `protobuf:"varint,5,opt,name=wholeWord,proto3" json:"wholeWord,omitempty"`
+	MaxCount      int64                  `protobuf:"varint,6,opt,name=maxCount,proto3" json:"maxCount,omitempty"`           // stop reading a file after this many selected lines, 0 for no limit
+	BeforeContext int32                  `protobuf:"varint,7,opt,name=beforeContext,proto3" json:"beforeContext,omitempty"` // lines of leading context
+	AfterContext  int32                  `protobuf:"varint,8,opt,name=afterContext,proto3" json:"afterContext,omitempty"`   // lines of trailing context
+	LineNumbers   bool                   `protobuf:"varint,9,opt,name=lineNumbers,proto3" json:"lineNumbers,omitempty"`
+	OnlyMatching  bool                   `protobuf:"varint,10,opt,name=onlyMatching,proto3" json:"onlyMatching,omitempty"`
+	LineRegexp    bool                   `protobuf:"varint,11,opt,name=lineRegexp,proto3" json:"lineRegexp,omitempty"` // a pattern must match the whole line; overrides wholeWord
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_grep_proto_rawDesc = "" +
-	"\n" +
-	"\n" +
-	"grep.proto\x12\x04grep\"E\n" +
-	"\rSearchRequest\x12 \n" +
-	"\vgrepOptions\x18\x01 \x03(\tR\vgrepOptions\x12\x12\n" +
-	"\x04mode\x18\x02 \x01(\tR\x04mode\"h\n" +
-	"\x0eSearchResponse\x12\x12\n" +
-	"\x04host\x18\x01 \x01(\tR\x04host\x12\x1a\n" +
-	"\bfilePath\x18\x02 \x01(\tR\bfilePath\x12\x10\n" +
-	"\x03log\x18\x03 \x01(\tR\x03log\x12\x14\n" +
-	"\x05count\x18\x04 \x01(\x03R\x05count2D\n" +
-	"\vGrepService\x125\n" +
-	"\x06Search\x12\x13.grep.SearchRequest\x1a\x14.grep.SearchResponse0\x01B\x16Z\x14MP1/protoBuilds;grepb\x06proto3"
+func (x *Query) Reset() {
+	*x = Query{}
+	mi := &file_grep_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Query) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Query) ProtoMessage() {}
+
+func (x *Query) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Query.ProtoReflect.Descriptor instead.
+func (*Query) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Query) GetPatterns() []string {
+	if x != nil {
+		return x.Patterns
+	}
+	return nil
+}
+
+func (x *Query) GetSyntax() PatternSyntax {
+	if x != nil {
+		return x.Syntax
+	}
+	return PatternSyntax_BASIC
+}
+
+func (x *Query) GetIgnoreCase() bool {
+	if x != nil {
+		return x.IgnoreCase
+	}
+	return false
+}
+
+func (x *Query) GetInvert() bool {
+	if x != nil {
+		return x.Invert
+	}
+	return false
+}
+
+func (x *Query) GetWholeWord() bool {
+	if x != nil {
+		return x.WholeWord
+	}
+	return false
+}
+
+func (x *Query) GetMaxCount() int64 {
+	if x != nil {
+		return x.MaxCount
+	}
+	return 0
+}
+
+func (x *Query) GetBeforeContext() int32 {
+	if x != nil {
+		return x.BeforeContext
+	}
+	return 0
+}
+
+func (x *Query) GetAfterContext() int32 {
+	if x != nil {
+		return x.AfterContext
+	}
+	return 0
+}
+
+func (x *Query) GetLineNumbers() bool {
+	if x != nil {
+		return x.LineNumbers
+	}
+	return false
+}
+
+func (x *Query) GetOnlyMatching() bool {
+	if x != nil {
+		return x.OnlyMatching
+	}
+	return false
+}
+
+func (x *Query) GetLineRegexp() bool {
+	if x != nil {
+		return x.LineRegexp
+	}
+	return false
+}
+
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`              // worker label
+	FilePath      string                 `protobuf:"bytes,2,opt,name=filePath,proto3" json:"filePath,omitempty"`      // when mode=="lines"
+	Log           string                 `protobuf:"bytes,3,opt,name=log,proto3" json:"log,omitempty"`                // when mode=="lines", the line as it is in the file
+	Count         int64                  `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`           // when mode=="count", sum across files on worker
+	LineNumber    int64                  `protobuf:"varint,5,opt,name=lineNumber,proto3" json:"lineNumber,omitempty"` // 1-based line number of log in filePath, 0 if not a file line
+	Shard         string                 `protobuf:"bytes,6,opt,name=shard,proto3" json:"shard,omitempty"`            // primary whose logs produced this response
+	Kind          LineKind               `protobuf:"varint,7,opt,name=kind,proto3,enum=grep.LineKind" json:"kind,omitempty"`
+	Hunk          int64                  `protobuf:"varint,8,opt,name=hunk,proto3" json:"hunk,omitempty"`              // with context lines, the group of adjacent lines this one belongs to, numbered from 1 within the stream; 0 without
+	ByteOffset    int64                  `protobuf:"varint,9,opt,name=byteOffset,proto3" json:"byteOffset,omitempty"`  // offset of the start of log in filePath (of the match with onlyMatching), counted after decompression
+	FileCounts    []*FileCount           `protobuf:"bytes,10,rep,name=fileCounts,proto3" json:"fileCounts,omitempty"`  // when mode=="count", the count for each file searched; count is their sum
+	Groups        []*GroupCount          `protobuf:"bytes,11,rep,name=groups,proto3" json:"groups,omitempty"`          // when mode=="aggregate", partial counts per key, spread over several responses; count is the number of selected lines
+	OtherCount    int64                  `protobuf:"varint,12,opt,name=otherCount,proto3" json:"otherCount,omitempty"` // when mode=="aggregate", lines not counted under a key in groups because maxGroups was reached
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_grep_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SearchResponse) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *SearchResponse) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *SearchResponse) GetLog() string {
+	if x != nil {
+		return x.Log
+	}
+	return ""
+}
+
+func (x *SearchResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetLineNumber() int64 {
+	if x != nil {
+		return x.LineNumber
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetShard() string {
+	if x != nil {
+		return x.Shard
+	}
+	return ""
+}
+
+func (x *SearchResponse) GetKind() LineKind {
+	if x != nil {
+		return x.Kind
+	}
+	return LineKind_MATCH
+}
+
+func (x *SearchResponse) GetHunk() int64 {
+	if x != nil {
+		return x.Hunk
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetByteOffset() int64 {
+	if x != nil {
+		return x.ByteOffset
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetFileCounts() []*FileCount {
+	if x != nil {
+		return x.FileCounts
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetGroups() []*GroupCount {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetOtherCount() int64 {
+	if x != nil {
+		return x.OtherCount
+	}
+	return 0
+}
+
+type GroupCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupCount) Reset() {
+	*x = GroupCount{}
+	mi := &file_grep_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupCount) ProtoMessage() {}
+
+func (x *GroupCount) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupCount.ProtoReflect.Descriptor instead.
+func (*GroupCount) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GroupCount) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *GroupCount) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type FileCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilePath      string                 `protobuf:"bytes,1,opt,name=filePath,proto3" json:"filePath,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileCount) Reset() {
+	*x = FileCount{}
+	mi := &file_grep_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileCount) ProtoMessage() {}
+
+func (x *FileCount) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileCount.ProtoReflect.Descriptor instead.
+func (*FileCount) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FileCount) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *FileCount) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type LinesAroundRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilePath      string                 `protobuf:"bytes,1,opt,name=filePath,proto3" json:"filePath,omitempty"`      // as returned in SearchResponse.filePath, or its base name
+	ByteOffset    int64                  `protobuf:"varint,2,opt,name=byteOffset,proto3" json:"byteOffset,omitempty"` // as returned in SearchResponse.byteOffset
+	Before        int32                  `protobuf:"varint,3,opt,name=before,proto3" json:"before,omitempty"`         // lines wanted ahead of the line at byteOffset
+	After         int32                  `protobuf:"varint,4,opt,name=after,proto3" json:"after,omitempty"`           // lines wanted behind it
+	Shard         string                 `protobuf:"bytes,5,opt,name=shard,proto3" json:"shard,omitempty"`            // as in SearchRequest
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinesAroundRequest) Reset() {
+	*x = LinesAroundRequest{}
+	mi := &file_grep_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinesAroundRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinesAroundRequest) ProtoMessage() {}
+
+func (x *LinesAroundRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinesAroundRequest.ProtoReflect.Descriptor instead.
+func (*LinesAroundRequest) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LinesAroundRequest) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *LinesAroundRequest) GetByteOffset() int64 {
+	if x != nil {
+		return x.ByteOffset
+	}
+	return 0
+}
+
+func (x *LinesAroundRequest) GetBefore() int32 {
+	if x != nil {
+		return x.Before
+	}
+	return 0
+}
+
+func (x *LinesAroundRequest) GetAfter() int32 {
+	if x != nil {
+		return x.After
+	}
+	return 0
+}
+
+func (x *LinesAroundRequest) GetShard() string {
+	if x != nil {
+		return x.Shard
+	}
+	return ""
+}
+
+type ReadRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilePath      string                 `protobuf:"bytes,1,opt,name=filePath,proto3" json:"filePath,omitempty"` // as returned in SearchResponse.filePath, or its base name
+	Shard         string                 `protobuf:"bytes,2,opt,name=shard,proto3" json:"shard,omitempty"`       // as in SearchRequest
+	Unit          RangeUnit              `protobuf:"varint,3,opt,name=unit,proto3,enum=grep.RangeUnit" json:"unit,omitempty"`
+	Start         int64                  `protobuf:"varint,4,opt,name=start,proto3" json:"start,omitempty"` // first line, from 1, or first byte offset, from 0
+	End           int64                  `protobuf:"varint,5,opt,name=end,proto3" json:"end,omitempty"`     // last line, inclusive, or byte offset just past the range; 0 reads to the end of the file
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadRangeRequest) Reset() {
+	*x = ReadRangeRequest{}
+	mi := &file_grep_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadRangeRequest) ProtoMessage() {}
+
+func (x *ReadRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadRangeRequest.ProtoReflect.Descriptor instead.
+func (*ReadRangeRequest) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ReadRangeRequest) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *ReadRangeRequest) GetShard() string {
+	if x != nil {
+		return x.Shard
+	}
+	return ""
+}
+
+func (x *ReadRangeRequest) GetUnit() RangeUnit {
+	if x != nil {
+		return x.Unit
+	}
+	return RangeUnit_LINES
+}
+
+func (x *ReadRangeRequest) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *ReadRangeRequest) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type FileChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        int64                  `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"` // of data in the file, counted after decompression
+	Line          int64                  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`     // number of the first line in data, for LINES ranges; 0 for BYTES
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`      // whole lines, newline-terminated, for LINES ranges
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileChunk) Reset() {
+	*x = FileChunk{}
+	mi := &file_grep_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileChunk) ProtoMessage() {}
+
+func (x *FileChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileChunk.ProtoReflect.Descriptor instead.
+func (*FileChunk) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FileChunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *FileChunk) GetLine() int64 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *FileChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ListFilesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Shard         string                 `protobuf:"bytes,1,opt,name=shard,proto3" json:"shard,omitempty"` // as in SearchRequest
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFilesRequest) Reset() {
+	*x = ListFilesRequest{}
+	mi := &file_grep_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFilesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFilesRequest) ProtoMessage() {}
+
+func (x *ListFilesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFilesRequest.ProtoReflect.Descriptor instead.
+func (*ListFilesRequest) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListFilesRequest) GetShard() string {
+	if x != nil {
+		return x.Shard
+	}
+	return ""
+}
+
+type LogFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"` // bytes on disk
+	ModTime       *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=modTime,proto3" json:"modTime,omitempty"`
+	Compression   string                 `protobuf:"bytes,4,opt,name=compression,proto3" json:"compression,omitempty"` // "none", "gzip", "zstd", "bzip2" or "xz"
+	Lines         int64                  `protobuf:"varint,5,opt,name=lines,proto3" json:"lines,omitempty"`
+	LinesExact    bool                   `protobuf:"varint,6,opt,name=linesExact,proto3" json:"linesExact,omitempty"` // whether lines was counted rather than estimated from a sample
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`            // set if the file could not be read
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogFile) Reset() {
+	*x = LogFile{}
+	mi := &file_grep_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogFile) ProtoMessage() {}
+
+func (x *LogFile) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogFile.ProtoReflect.Descriptor instead.
+func (*LogFile) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *LogFile) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *LogFile) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *LogFile) GetModTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ModTime
+	}
+	return nil
+}
+
+func (x *LogFile) GetCompression() string {
+	if x != nil {
+		return x.Compression
+	}
+	return ""
+}
+
+func (x *LogFile) GetLines() int64 {
+	if x != nil {
+		return x.Lines
+	}
+	return 0
+}
+
+func (x *LogFile) GetLinesExact() bool {
+	if x != nil {
+		return x.LinesExact
+	}
+	return false
+}
+
+func (x *LogFile) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ListFilesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"` // worker label
+	Shard         string                 `protobuf:"bytes,2,opt,name=shard,proto3" json:"shard,omitempty"`
+	Dir           string                 `protobuf:"bytes,3,opt,name=dir,proto3" json:"dir,omitempty"` // directory searched
+	Glob          string                 `protobuf:"bytes,4,opt,name=glob,proto3" json:"glob,omitempty"`
+	Rotated       bool                   `protobuf:"varint,5,opt,name=rotated,proto3" json:"rotated,omitempty"` // whether rotated copies (glob + ".*") are searched too
+	Files         []*LogFile             `protobuf:"bytes,6,rep,name=files,proto3" json:"files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFilesResponse) Reset() {
+	*x = ListFilesResponse{}
+	mi := &file_grep_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFilesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFilesResponse) ProtoMessage() {}
+
+func (x *ListFilesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFilesResponse.ProtoReflect.Descriptor instead.
+func (*ListFilesResponse) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListFilesResponse) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *ListFilesResponse) GetShard() string {
+	if x != nil {
+		return x.Shard
+	}
+	return ""
+}
+
+func (x *ListFilesResponse) GetDir() string {
+	if x != nil {
+		return x.Dir
+	}
+	return ""
+}
+
+func (x *ListFilesResponse) GetGlob() string {
+	if x != nil {
+		return x.Glob
+	}
+	return ""
+}
+
+func (x *ListFilesResponse) GetRotated() bool {
+	if x != nil {
+		return x.Rotated
+	}
+	return false
+}
+
+func (x *ListFilesResponse) GetFiles() []*LogFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+type ReplicaChunk struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Shard    string                 `protobuf:"bytes,1,opt,name=shard,proto3" json:"shard,omitempty"`       // primary the data belongs to
+	FileName string                 `protobuf:"bytes,2,opt,name=fileName,proto3" json:"fileName,omitempty"` // base name of the log file on the primary; empty to only query sizes
+	Offset   int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`    // where data starts in the file; 0 rewrites the replica
+	Data     []byte                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	// Every file the primary has now, sent on the first chunk of a push; the
+	// holder deletes its replicas of any other. Unset to delete nothing.
+	Present       *ReplicaFiles `protobuf:"bytes,5,opt,name=present,proto3" json:"present,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaChunk) Reset() {
+	*x = ReplicaChunk{}
+	mi := &file_grep_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaChunk) ProtoMessage() {}
+
+func (x *ReplicaChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaChunk.ProtoReflect.Descriptor instead.
+func (*ReplicaChunk) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ReplicaChunk) GetShard() string {
+	if x != nil {
+		return x.Shard
+	}
+	return ""
+}
+
+func (x *ReplicaChunk) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *ReplicaChunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ReplicaChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ReplicaChunk) GetPresent() *ReplicaFiles {
+	if x != nil {
+		return x.Present
+	}
+	return nil
+}
+
+type ReplicaFiles struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Names []string               `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"` // base names
+	// The primary's -glob and -rotate, so the holder searches its copies the
+	// way the primary searches the originals.
+	Glob          string `protobuf:"bytes,2,opt,name=glob,proto3" json:"glob,omitempty"`
+	Rotated       bool   `protobuf:"varint,3,opt,name=rotated,proto3" json:"rotated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaFiles) Reset() {
+	*x = ReplicaFiles{}
+	mi := &file_grep_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaFiles) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaFiles) ProtoMessage() {}
+
+func (x *ReplicaFiles) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaFiles.ProtoReflect.Descriptor instead.
+func (*ReplicaFiles) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ReplicaFiles) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+func (x *ReplicaFiles) GetGlob() string {
+	if x != nil {
+		return x.Glob
+	}
+	return ""
+}
+
+func (x *ReplicaFiles) GetRotated() bool {
+	if x != nil {
+		return x.Rotated
+	}
+	return false
+}
+
+type ReplicaAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sizes         map[string]int64       `protobuf:"bytes,1,rep,name=sizes,proto3" json:"sizes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // size of every replica file held for the shard
+	Heads         map[string][]byte      `protobuf:"bytes,2,rep,name=heads,proto3" json:"heads,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`  // SHA-256 of the first 4 KiB of each file (all of it if shorter), to tell a file from a rotated one of the same name
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaAck) Reset() {
+	*x = ReplicaAck{}
+	mi := &file_grep_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaAck) ProtoMessage() {}
+
+func (x *ReplicaAck) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaAck.ProtoReflect.Descriptor instead.
+func (*ReplicaAck) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ReplicaAck) GetSizes() map[string]int64 {
+	if x != nil {
+		return x.Sizes
+	}
+	return nil
+}
+
+func (x *ReplicaAck) GetHeads() map[string][]byte {
+	if x != nil {
+		return x.Heads
+	}
+	return nil
+}
+
+type Member struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"` // peer.machine.nameN
+	Addr          string                 `protobuf:"bytes,2,opt,name=addr,proto3" json:"addr,omitempty"`   // host:port
+	State         MemberState            `protobuf:"varint,3,opt,name=state,proto3,enum=grep.MemberState" json:"state,omitempty"`
+	Incarnation   uint64                 `protobuf:"varint,4,opt,name=incarnation,proto3" json:"incarnation,omitempty"` // raised by the member itself to refute suspicion
+	Globs         []string               `protobuf:"bytes,5,rep,name=globs,proto3" json:"globs,omitempty"`              // log file globs the member searches
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Member) Reset() {
+	*x = Member{}
+	mi := &file_grep_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Member) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Member) ProtoMessage() {}
+
+func (x *Member) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Member.ProtoReflect.Descriptor instead.
+func (*Member) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Member) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Member) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *Member) GetState() MemberState {
+	if x != nil {
+		return x.State
+	}
+	return MemberState_ALIVE
+}
+
+func (x *Member) GetIncarnation() uint64 {
+	if x != nil {
+		return x.Incarnation
+	}
+	return 0
+}
+
+func (x *Member) GetGlobs() []string {
+	if x != nil {
+		return x.Globs
+	}
+	return nil
+}
+
+type PingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`       // label of the prober
+	Updates       []*Member              `protobuf:"bytes,2,rep,name=updates,proto3" json:"updates,omitempty"` // the prober's view of the cluster
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingRequest) Reset() {
+	*x = PingRequest{}
+	mi := &file_grep_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingRequest) ProtoMessage() {}
+
+func (x *PingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *PingRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *PingRequest) GetUpdates() []*Member {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+type PingReqRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"` // address to probe on the requester's behalf
+	Updates       []*Member              `protobuf:"bytes,3,rep,name=updates,proto3" json:"updates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingReqRequest) Reset() {
+	*x = PingReqRequest{}
+	mi := &file_grep_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingReqRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingReqRequest) ProtoMessage() {}
+
+func (x *PingReqRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingReqRequest.ProtoReflect.Descriptor instead.
+func (*PingReqRequest) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PingReqRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *PingReqRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *PingReqRequest) GetUpdates() []*Member {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+type PingAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Updates       []*Member              `protobuf:"bytes,1,rep,name=updates,proto3" json:"updates,omitempty"` // the responder's view of the cluster
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingAck) Reset() {
+	*x = PingAck{}
+	mi := &file_grep_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingAck) ProtoMessage() {}
+
+func (x *PingAck) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingAck.ProtoReflect.Descriptor instead.
+func (*PingAck) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PingAck) GetUpdates() []*Member {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+type MembersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MembersRequest) Reset() {
+	*x = MembersRequest{}
+	mi := &file_grep_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MembersRequest) ProtoMessage() {}
+
+func (x *MembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MembersRequest.ProtoReflect.Descriptor instead.
+func (*MembersRequest) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{19}
+}
+
+type MembersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Members       []*Member              `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"` // every member known to the worker, itself included
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MembersResponse) Reset() {
+	*x = MembersResponse{}
+	mi := &file_grep_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MembersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MembersResponse) ProtoMessage() {}
+
+func (x *MembersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MembersResponse.ProtoReflect.Descriptor instead.
+func (*MembersResponse) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *MembersResponse) GetMembers() []*Member {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type RegisterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Member        *Member                `protobuf:"bytes,1,opt,name=member,proto3" json:"member,omitempty"` // the worker joining
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	mi := &file_grep_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *RegisterRequest) GetMember() *Member {
+	if x != nil {
+		return x.Member
+	}
+	return nil
+}
+
+type RegisterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Members       []*Member              `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"` // the introducer's view of the cluster
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterResponse) Reset() {
+	*x = RegisterResponse{}
+	mi := &file_grep_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterResponse) ProtoMessage() {}
+
+func (x *RegisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterResponse.ProtoReflect.Descriptor instead.
+func (*RegisterResponse) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *RegisterResponse) GetMembers() []*Member {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type DeregisterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Member        *Member                `protobuf:"bytes,1,opt,name=member,proto3" json:"member,omitempty"` // the worker leaving, in state LEFT
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeregisterRequest) Reset() {
+	*x = DeregisterRequest{}
+	mi := &file_grep_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeregisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeregisterRequest) ProtoMessage() {}
+
+func (x *DeregisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeregisterRequest.ProtoReflect.Descriptor instead.
+func (*DeregisterRequest) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DeregisterRequest) GetMember() *Member {
+	if x != nil {
+		return x.Member
+	}
+	return nil
+}
+
+type DeregisterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeregisterResponse) Reset() {
+	*x = DeregisterResponse{}
+	mi := &file_grep_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeregisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeregisterResponse) ProtoMessage() {}
+
+func (x *DeregisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeregisterResponse.ProtoReflect.Descriptor instead.
+func (*DeregisterResponse) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{24}
+}
+
+type AuditRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`  // only searches started at or after this time
+	Until         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=until,proto3" json:"until,omitempty"`  // only searches started at or before this time
+	User          string                 `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`    // only searches by this user; empty for all
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"` // only the newest this many matching records; 0 for all
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditRequest) Reset() {
+	*x = AuditRequest{}
+	mi := &file_grep_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditRequest) ProtoMessage() {}
+
+func (x *AuditRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditRequest.ProtoReflect.Descriptor instead.
+func (*AuditRequest) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *AuditRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *AuditRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+func (x *AuditRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *AuditRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// AuditRecord describes one Search call. Workers append them, as JSON, to
+// their audit log.
+type AuditRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"` // when the search started
+	Host          string                 `protobuf:"bytes,2,opt,name=host,proto3" json:"host,omitempty"` // worker label
+	Peer          string                 `protobuf:"bytes,3,opt,name=peer,proto3" json:"peer,omitempty"` // caller's address
+	User          string                 `protobuf:"bytes,4,opt,name=user,proto3" json:"user,omitempty"` // caller's ACL user or certificate name; empty if unknown
+	Request       *SearchRequest         `protobuf:"bytes,5,opt,name=request,proto3" json:"request,omitempty"`
+	Files         []string               `protobuf:"bytes,6,rep,name=files,proto3" json:"files,omitempty"`  // files searched or followed
+	Lines         int64                  `protobuf:"varint,7,opt,name=lines,proto3" json:"lines,omitempty"` // file lines sent, context lines included
+	Count         int64                  `protobuf:"varint,8,opt,name=count,proto3" json:"count,omitempty"` // selected lines counted, in count and aggregate modes and with grep -c
+	DurationMs    int64                  `protobuf:"varint,9,opt,name=durationMs,proto3" json:"durationMs,omitempty"`
+	Code          string                 `protobuf:"bytes,10,opt,name=code,proto3" json:"code,omitempty"`   // gRPC status code the search ended with, e.g. OK or PermissionDenied
+	Error         string                 `protobuf:"bytes,11,opt,name=error,proto3" json:"error,omitempty"` // status message when code is not OK
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditRecord) Reset() {
+	*x = AuditRecord{}
+	mi := &file_grep_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditRecord) ProtoMessage() {}
+
+func (x *AuditRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_grep_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditRecord.ProtoReflect.Descriptor instead.
+func (*AuditRecord) Descriptor() ([]byte, []int) {
+	return file_grep_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *AuditRecord) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *AuditRecord) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *AuditRecord) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *AuditRecord) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *AuditRecord) GetRequest() *SearchRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *AuditRecord) GetFiles() []string {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *AuditRecord) GetLines() int64 {
+	if x != nil {
+		return x.Lines
+	}
+	return 0
+}
+
+func (x *AuditRecord) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *AuditRecord) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *AuditRecord) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *AuditRecord) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_grep_proto protoreflect.FileDescriptor
+
+const file_grep_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"grep.proto\x12\x04grep\x1a\x1fgoogle/protobuf/timestamp.proto\"\xcb\x02\n" +
+	"\rSearchRequest\x12 \n" +
+	"\vgrepOptions\x18\x01 \x03(\tR\vgrepOptions\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\tR\x04mode\x12!\n" +
+	"\x05query\x18\x03 \x01(\v2\v.grep.QueryR\x05query\x120\n" +
+	"\x05since\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12\x14\n" +
+	"\x05shard\x18\x06 \x01(\tR\x05shard\x12\x16\n" +
+	"\x06follow\x18\a \x01(\bR\x06follow\x12\x1e\n" +
+	"\n" +
+	"maxResults\x18\b \x01(\x03R\n" +
+	"maxResults\x12/\n" +
+	"\taggregate\x18\t \x01(\v2\x11.grep.AggregationR\taggregate\"\x92\x01\n" +
+	"\vAggregation\x12\x1d\n" +
+	"\x02by\x18\x01 \x01(\x0e2\r.grep.GroupByR\x02by\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\x05R\x05index\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1c\n" +
+	"\tseparator\x18\x04 \x01(\tR\tseparator\x12\x1c\n" +
+	"\tmaxGroups\x18\x05 \x01(\x05R\tmaxGroups\"\xf2\x02\n" +
+	"\x05Query\x12\x1a\n" +
+	"\bpatterns\x18\x01 \x03(\tR\bpatterns\x12+\n" +
+	"\x06syntax\x18\x02 \x01(\x0e2\x13.grep.PatternSyntaxR\x06syntax\x12\x1e\n" +
+	"\n" +
+	"ignoreCase\x18\x03 \x01(\bR\n" +
+	"ignoreCase\x12\x16\n" +
+	"\x06invert\x18\x04 \x01(\bR\x06invert\x12\x1c\n" +
+	"\twholeWord\x18\x05 \x01(\bR\twholeWord\x12\x1a\n" +
+	"\bmaxCount\x18\x06 \x01(\x03R\bmaxCount\x12$\n" +
+	"\rbeforeContext\x18\a \x01(\x05R\rbeforeContext\x12\"\n" +
+	"\fafterContext\x18\b \x01(\x05R\fafterContext\x12 \n" +
+	"\vlineNumbers\x18\t \x01(\bR\vlineNumbers\x12\"\n" +
+	"\fonlyMatching\x18\n" +
+	" \x01(\bR\fonlyMatching\x12\x1e\n" +
+	"\n" +
+	"lineRegexp\x18\v \x01(\bR\n" +
+	"lineRegexp\"\xf1\x02\n" +
+	"\x0eSearchResponse\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12\x1a\n" +
+	"\bfilePath\x18\x02 \x01(\tR\bfilePath\x12\x10\n" +
+	"\x03log\x18\x03 \x01(\tR\x03log\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x03R\x05count\x12\x1e\n" +
+	"\n" +
+	"lineNumber\x18\x05 \x01(\x03R\n" +
+	"lineNumber\x12\x14\n" +
+	"\x05shard\x18\x06 \x01(\tR\x05shard\x12\"\n" +
+	"\x04kind\x18\a \x01(\x0e2\x0e.grep.LineKindR\x04kind\x12\x12\n" +
+	"\x04hunk\x18\b \x01(\x03R\x04hunk\x12\x1e\n" +
+	"\n" +
+	"byteOffset\x18\t \x01(\x03R\n" +
+	"byteOffset\x12/\n" +
+	"\n" +
+	"fileCounts\x18\n" +
+	" \x03(\v2\x0f.grep.FileCountR\n" +
+	"fileCounts\x12(\n" +
+	"\x06groups\x18\v \x03(\v2\x10.grep.GroupCountR\x06groups\x12\x1e\n" +
+	"\n" +
+	"otherCount\x18\f \x01(\x03R\n" +
+	"otherCount\"4\n" +
+	"\n" +
+	"GroupCount\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"=\n" +
+	"\tFileCount\x12\x1a\n" +
+	"\bfilePath\x18\x01 \x01(\tR\bfilePath\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"\x94\x01\n" +
+	"\x12LinesAroundRequest\x12\x1a\n" +
+	"\bfilePath\x18\x01 \x01(\tR\bfilePath\x12\x1e\n" +
+	"\n" +
+	"byteOffset\x18\x02 \x01(\x03R\n" +
+	"byteOffset\x12\x16\n" +
+	"\x06before\x18\x03 \x01(\x05R\x06before\x12\x14\n" +
+	"\x05after\x18\x04 \x01(\x05R\x05after\x12\x14\n" +
+	"\x05shard\x18\x05 \x01(\tR\x05shard\"\x91\x01\n" +
+	"\x10ReadRangeRequest\x12\x1a\n" +
+	"\bfilePath\x18\x01 \x01(\tR\bfilePath\x12\x14\n" +
+	"\x05shard\x18\x02 \x01(\tR\x05shard\x12#\n" +
+	"\x04unit\x18\x03 \x01(\x0e2\x0f.grep.RangeUnitR\x04unit\x12\x14\n" +
+	"\x05start\x18\x04 \x01(\x03R\x05start\x12\x10\n" +
+	"\x03end\x18\x05 \x01(\x03R\x03end\"K\n" +
+	"\tFileChunk\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\x03R\x04line\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"(\n" +
+	"\x10ListFilesRequest\x12\x14\n" +
+	"\x05shard\x18\x01 \x01(\tR\x05shard\"\xd5\x01\n" +
+	"\aLogFile\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x124\n" +
+	"\amodTime\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\amodTime\x12 \n" +
+	"\vcompression\x18\x04 \x01(\tR\vcompression\x12\x14\n" +
+	"\x05lines\x18\x05 \x01(\x03R\x05lines\x12\x1e\n" +
+	"\n" +
+	"linesExact\x18\x06 \x01(\bR\n" +
+	"linesExact\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\"\xa2\x01\n" +
+	"\x11ListFilesResponse\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12\x14\n" +
+	"\x05shard\x18\x02 \x01(\tR\x05shard\x12\x10\n" +
+	"\x03dir\x18\x03 \x01(\tR\x03dir\x12\x12\n" +
+	"\x04glob\x18\x04 \x01(\tR\x04glob\x12\x18\n" +
+	"\arotated\x18\x05 \x01(\bR\arotated\x12#\n" +
+	"\x05files\x18\x06 \x03(\v2\r.grep.LogFileR\x05files\"\x9a\x01\n" +
+	"\fReplicaChunk\x12\x14\n" +
+	"\x05shard\x18\x01 \x01(\tR\x05shard\x12\x1a\n" +
+	"\bfileName\x18\x02 \x01(\tR\bfileName\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\x12,\n" +
+	"\apresent\x18\x05 \x01(\v2\x12.grep.ReplicaFilesR\apresent\"R\n" +
+	"\fReplicaFiles\x12\x14\n" +
+	"\x05names\x18\x01 \x03(\tR\x05names\x12\x12\n" +
+	"\x04glob\x18\x02 \x01(\tR\x04glob\x12\x18\n" +
+	"\arotated\x18\x03 \x01(\bR\arotated\"\xe6\x01\n" +
+	"\n" +
+	"ReplicaAck\x121\n" +
+	"\x05sizes\x18\x01 \x03(\v2\x1b.grep.ReplicaAck.SizesEntryR\x05sizes\x121\n" +
+	"\x05heads\x18\x02 \x03(\v2\x1b.grep.ReplicaAck.HeadsEntryR\x05heads\x1a8\n" +
+	"\n" +
+	"SizesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\x1a8\n" +
+	"\n" +
+	"HeadsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"\x93\x01\n" +
+	"\x06Member\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\tR\x05label\x12\x12\n" +
+	"\x04addr\x18\x02 \x01(\tR\x04addr\x12'\n" +
+	"\x05state\x18\x03 \x01(\x0e2\x11.grep.MemberStateR\x05state\x12 \n" +
+	"\vincarnation\x18\x04 \x01(\x04R\vincarnation\x12\x14\n" +
+	"\x05globs\x18\x05 \x03(\tR\x05globs\"I\n" +
+	"\vPingRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12&\n" +
+	"\aupdates\x18\x02 \x03(\v2\f.grep.MemberR\aupdates\"d\n" +
+	"\x0ePingReqRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x12&\n" +
+	"\aupdates\x18\x03 \x03(\v2\f.grep.MemberR\aupdates\"1\n" +
+	"\aPingAck\x12&\n" +
+	"\aupdates\x18\x01 \x03(\v2\f.grep.MemberR\aupdates\"\x10\n" +
+	"\x0eMembersRequest\"9\n" +
+	"\x0fMembersResponse\x12&\n" +
+	"\amembers\x18\x01 \x03(\v2\f.grep.MemberR\amembers\"7\n" +
+	"\x0fRegisterRequest\x12$\n" +
+	"\x06member\x18\x01 \x01(\v2\f.grep.MemberR\x06member\":\n" +
+	"\x10RegisterResponse\x12&\n" +
+	"\amembers\x18\x01 \x03(\v2\f.grep.MemberR\amembers\"9\n" +
+	"\x11DeregisterRequest\x12$\n" +
+	"\x06member\x18\x01 \x01(\v2\f.grep.MemberR\x06member\"\x14\n" +
+	"\x12DeregisterResponse\"\x9c\x01\n" +
+	"\fAuditRequest\x120\n" +
+	"\x05since\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12\x12\n" +
+	"\x04user\x18\x03 \x01(\tR\x04user\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"\xb4\x02\n" +
+	"\vAuditRecord\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x12\n" +
+	"\x04host\x18\x02 \x01(\tR\x04host\x12\x12\n" +
+	"\x04peer\x18\x03 \x01(\tR\x04peer\x12\x12\n" +
+	"\x04user\x18\x04 \x01(\tR\x04user\x12-\n" +
+	"\arequest\x18\x05 \x01(\v2\x13.grep.SearchRequestR\arequest\x12\x14\n" +
+	"\x05files\x18\x06 \x03(\tR\x05files\x12\x14\n" +
+	"\x05lines\x18\a \x01(\x03R\x05lines\x12\x14\n" +
+	"\x05count\x18\b \x01(\x03R\x05count\x12\x1e\n" +
+	"\n" +
+	"durationMs\x18\t \x01(\x03R\n" +
+	"durationMs\x12\x12\n" +
+	"\x04code\x18\n" +
+	" \x01(\tR\x04code\x12\x14\n" +
+	"\x05error\x18\v \x01(\tR\x05error*!\n" +
+	"\aGroupBy\x12\v\n" +
+	"\aCAPTURE\x10\x00\x12\t\n" +
+	"\x05FIELD\x10\x01*=\n" +
+	"\rPatternSyntax\x12\t\n" +
+	"\x05BASIC\x10\x00\x12\t\n" +
+	"\x05FIXED\x10\x01\x12\f\n" +
+	"\bEXTENDED\x10\x02\x12\b\n" +
+	"\x04PCRE\x10\x03*\"\n" +
+	"\bLineKind\x12\t\n" +
+	"\x05MATCH\x10\x00\x12\v\n" +
+	"\aCONTEXT\x10\x01*!\n" +
+	"\tRangeUnit\x12\t\n" +
+	"\x05LINES\x10\x00\x12\t\n" +
+	"\x05BYTES\x10\x01*;\n" +
+	"\vMemberState\x12\t\n" +
+	"\x05ALIVE\x10\x00\x12\v\n" +
+	"\aSUSPECT\x10\x01\x12\n" +
+	"\n" +
+	"\x06FAILED\x10\x02\x12\b\n" +
+	"\x04LEFT\x10\x032\xf0\x04\n" +
+	"\vGrepService\x125\n" +
+	"\x06Search\x12\x13.grep.SearchRequest\x1a\x14.grep.SearchResponse0\x01\x12?\n" +
+	"\vLinesAround\x12\x18.grep.LinesAroundRequest\x1a\x14.grep.SearchResponse0\x01\x126\n" +
+	"\tReadRange\x12\x16.grep.ReadRangeRequest\x1a\x0f.grep.FileChunk0\x01\x12<\n" +
+	"\tListFiles\x12\x16.grep.ListFilesRequest\x1a\x17.grep.ListFilesResponse\x123\n" +
+	"\tReplicate\x12\x12.grep.ReplicaChunk\x1a\x10.grep.ReplicaAck(\x01\x12(\n" +
+	"\x04Ping\x12\x11.grep.PingRequest\x1a\r.grep.PingAck\x12.\n" +
+	"\aPingReq\x12\x14.grep.PingReqRequest\x1a\r.grep.PingAck\x126\n" +
+	"\aMembers\x12\x14.grep.MembersRequest\x1a\x15.grep.MembersResponse\x129\n" +
+	"\bRegister\x12\x15.grep.RegisterRequest\x1a\x16.grep.RegisterResponse\x12?\n" +
+	"\n" +
+	"Deregister\x12\x17.grep.DeregisterRequest\x1a\x18.grep.DeregisterResponse\x120\n" +
+	"\x05Audit\x12\x12.grep.AuditRequest\x1a\x11.grep.AuditRecord0\x01B\x16Z\x14MP1/protoBuilds;grepb\x06proto3"
 
 var (
 	file_grep_proto_rawDescOnce sync.Once
@@ -170,19 +2326,100 @@ func file_grep_proto_rawDescGZIP() []byte {
 	return file_grep_proto_rawDescData
 }
 
-var file_grep_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_grep_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_grep_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
 var file_grep_proto_goTypes = []any{
-	(*SearchRequest)(nil),  // 0: grep.SearchRequest
-	(*SearchResponse)(nil), // 1: grep.SearchResponse
+	(GroupBy)(0),                  // 0: grep.GroupBy
+	(PatternSyntax)(0),            // 1: grep.PatternSyntax
+	(LineKind)(0),                 // 2: grep.LineKind
+	(RangeUnit)(0),                // 3: grep.RangeUnit
+	(MemberState)(0),              // 4: grep.MemberState
+	(*SearchRequest)(nil),         // 5: grep.SearchRequest
+	(*Aggregation)(nil),           // 6: grep.Aggregation
+	(*Query)(nil),                 // 7: grep.Query
+	(*SearchResponse)(nil),        // 8: grep.SearchResponse
+	(*GroupCount)(nil),            // 9: grep.GroupCount
+	(*FileCount)(nil),             // 10: grep.FileCount
+	(*LinesAroundRequest)(nil),    // 11: grep.LinesAroundRequest
+	(*ReadRangeRequest)(nil),      // 12: grep.ReadRangeRequest
+	(*FileChunk)(nil),             // 13: grep.FileChunk
+	(*ListFilesRequest)(nil),      // 14: grep.ListFilesRequest
+	(*LogFile)(nil),               // 15: grep.LogFile
+	(*ListFilesResponse)(nil),     // 16: grep.ListFilesResponse
+	(*ReplicaChunk)(nil),          // 17: grep.ReplicaChunk
+	(*ReplicaFiles)(nil),          // 18: grep.ReplicaFiles
+	(*ReplicaAck)(nil),            // 19: grep.ReplicaAck
+	(*Member)(nil),                // 20: grep.Member
+	(*PingRequest)(nil),           // 21: grep.PingRequest
+	(*PingReqRequest)(nil),        // 22: grep.PingReqRequest
+	(*PingAck)(nil),               // 23: grep.PingAck
+	(*MembersRequest)(nil),        // 24: grep.MembersRequest
+	(*MembersResponse)(nil),       // 25: grep.MembersResponse
+	(*RegisterRequest)(nil),       // 26: grep.RegisterRequest
+	(*RegisterResponse)(nil),      // 27: grep.RegisterResponse
+	(*DeregisterRequest)(nil),     // 28: grep.DeregisterRequest
+	(*DeregisterResponse)(nil),    // 29: grep.DeregisterResponse
+	(*AuditRequest)(nil),          // 30: grep.AuditRequest
+	(*AuditRecord)(nil),           // 31: grep.AuditRecord
+	nil,                           // 32: grep.ReplicaAck.SizesEntry
+	nil,                           // 33: grep.ReplicaAck.HeadsEntry
+	(*timestamppb.Timestamp)(nil), // 34: google.protobuf.Timestamp
 }
 var file_grep_proto_depIdxs = []int32{
-	0, // 0: grep.GrepService.Search:input_type -> grep.SearchRequest
-	1, // 1: grep.GrepService.Search:output_type -> grep.SearchResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	7,  // 0: grep.SearchRequest.query:type_name -> grep.Query
+	34, // 1: grep.SearchRequest.since:type_name -> google.protobuf.Timestamp
+	34, // 2: grep.SearchRequest.until:type_name -> google.protobuf.Timestamp
+	6,  // 3: grep.SearchRequest.aggregate:type_name -> grep.Aggregation
+	0,  // 4: grep.Aggregation.by:type_name -> grep.GroupBy
+	1,  // 5: grep.Query.syntax:type_name -> grep.PatternSyntax
+	2,  // 6: grep.SearchResponse.kind:type_name -> grep.LineKind
+	10, // 7: grep.SearchResponse.fileCounts:type_name -> grep.FileCount
+	9,  // 8: grep.SearchResponse.groups:type_name -> grep.GroupCount
+	3,  // 9: grep.ReadRangeRequest.unit:type_name -> grep.RangeUnit
+	34, // 10: grep.LogFile.modTime:type_name -> google.protobuf.Timestamp
+	15, // 11: grep.ListFilesResponse.files:type_name -> grep.LogFile
+	18, // 12: grep.ReplicaChunk.present:type_name -> grep.ReplicaFiles
+	32, // 13: grep.ReplicaAck.sizes:type_name -> grep.ReplicaAck.SizesEntry
+	33, // 14: grep.ReplicaAck.heads:type_name -> grep.ReplicaAck.HeadsEntry
+	4,  // 15: grep.Member.state:type_name -> grep.MemberState
+	20, // 16: grep.PingRequest.updates:type_name -> grep.Member
+	20, // 17: grep.PingReqRequest.updates:type_name -> grep.Member
+	20, // 18: grep.PingAck.updates:type_name -> grep.Member
+	20, // 19: grep.MembersResponse.members:type_name -> grep.Member
+	20, // 20: grep.RegisterRequest.member:type_name -> grep.Member
+	20, // 21: grep.RegisterResponse.members:type_name -> grep.Member
+	20, // 22: grep.DeregisterRequest.member:type_name -> grep.Member
+	34, // 23: grep.AuditRequest.since:type_name -> google.protobuf.Timestamp
+	34, // 24: grep.AuditRequest.until:type_name -> google.protobuf.Timestamp
+	34, // 25: grep.AuditRecord.time:type_name -> google.protobuf.Timestamp
+	5,  // 26: grep.AuditRecord.request:type_name -> grep.SearchRequest
+	5,  // 27: grep.GrepService.Search:input_type -> grep.SearchRequest
+	11, // 28: grep.GrepService.LinesAround:input_type -> grep.LinesAroundRequest
+	12, // 29: grep.GrepService.ReadRange:input_type -> grep.ReadRangeRequest
+	14, // 30: grep.GrepService.ListFiles:input_type -> grep.ListFilesRequest
+	17, // 31: grep.GrepService.Replicate:input_type -> grep.ReplicaChunk
+	21, // 32: grep.GrepService.Ping:input_type -> grep.PingRequest
+	22, // 33: grep.GrepService.PingReq:input_type -> grep.PingReqRequest
+	24, // 34: grep.GrepService.Members:input_type -> grep.MembersRequest
+	26, // 35: grep.GrepService.Register:input_type -> grep.RegisterRequest
+	28, // 36: grep.GrepService.Deregister:input_type -> grep.DeregisterRequest
+	30, // 37: grep.GrepService.Audit:input_type -> grep.AuditRequest
+	8,  // 38: grep.GrepService.Search:output_type -> grep.SearchResponse
+	8,  // 39: grep.GrepService.LinesAround:output_type -> grep.SearchResponse
+	13, // 40: grep.GrepService.ReadRange:output_type -> grep.FileChunk
+	16, // 41: grep.GrepService.ListFiles:output_type -> grep.ListFilesResponse
+	19, // 42: grep.GrepService.Replicate:output_type -> grep.ReplicaAck
+	23, // 43: grep.GrepService.Ping:output_type -> grep.PingAck
+	23, // 44: grep.GrepService.PingReq:output_type -> grep.PingAck
+	25, // 45: grep.GrepService.Members:output_type -> grep.MembersResponse
+	27, // 46: grep.GrepService.Register:output_type -> grep.RegisterResponse
+	29, // 47: grep.GrepService.Deregister:output_type -> grep.DeregisterResponse
+	31, // 48: grep.GrepService.Audit:output_type -> grep.AuditRecord
+	38, // [38:49] is the sub-list for method output_type
+	27, // [27:38] is the sub-list for method input_type
+	27, // [27:27] is the sub-list for extension type_name
+	27, // [27:27] is the sub-list for extension extendee
+	0,  // [0:27] is the sub-list for field type_name
 }
 
 func init() { file_grep_proto_init() }
@@ -195,13 +2432,14 @@ func file_grep_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_grep_proto_rawDesc), len(file_grep_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      5,
+			NumMessages:   29,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_grep_proto_goTypes,
 		DependencyIndexes: file_grep_proto_depIdxs,
+		EnumInfos:         file_grep_proto_enumTypes,
 		MessageInfos:      file_grep_proto_msgTypes,
 	}.Build()
 	File_grep_proto = out.File
@@ -19,7 +19,17 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	GrepService_Search_FullMethodName = "/grep.GrepService/Search"
+	GrepService_Search_FullMethodName      = "/grep.GrepService/Search"
+	GrepService_LinesAround_FullMethodName = "/grep.GrepService/LinesAround"
+	GrepService_ReadRange_FullMethodName   = "/grep.GrepService/ReadRange"
+	GrepService_ListFiles_FullMethodName   = "/grep.GrepService/ListFiles"
+	GrepService_Replicate_FullMethodName   = "/grep.GrepService/Replicate"
+	GrepService_Ping_FullMethodName        = "/grep.GrepService/Ping"
+	GrepService_PingReq_FullMethodName     = "/grep.GrepService/PingReq"
+	GrepService_Members_FullMethodName     = "/grep.GrepService/Members"
+	GrepService_Register_FullMethodName    = "/grep.GrepService/Register"
+	GrepService_Deregister_FullMethodName  = "/grep.GrepService/Deregister"
+	GrepService_Audit_FullMethodName       = "/grep.GrepService/Audit"
 )
 
 // GrepServiceClient is the client API for GrepService service.
@@ -27,6 +37,31 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type GrepServiceClient interface {
 	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SearchResponse], error)
+	// LinesAround returns the line at a byte offset reported by Search, as a
+	// MATCH, with CONTEXT lines around it.
+	LinesAround(ctx context.Context, in *LinesAroundRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SearchResponse], error)
+	// ReadRange streams a range of lines or bytes of one of a shard's log
+	// files, decompressed.
+	ReadRange(ctx context.Context, in *ReadRangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileChunk], error)
+	// ListFiles describes the files a search of a shard would read.
+	ListFiles(ctx context.Context, in *ListFilesRequest, opts ...grpc.CallOption) (*ListFilesResponse, error)
+	// Replicate stores copies of a peer's log files so they can be searched
+	// when that peer is down.
+	Replicate(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ReplicaChunk, ReplicaAck], error)
+	// Ping, PingReq and Members make up the failure detector: workers probe
+	// each other directly or through a third worker, piggybacking what they
+	// know about the cluster, and report their view through Members.
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingAck, error)
+	PingReq(ctx context.Context, in *PingReqRequest, opts ...grpc.CallOption) (*PingAck, error)
+	Members(ctx context.Context, in *MembersRequest, opts ...grpc.CallOption) (*MembersResponse, error)
+	// Register announces a worker to the cluster through an introducer, one
+	// of the seed workers in cluster.properties; Deregister announces that it
+	// is leaving.
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	Deregister(ctx context.Context, in *DeregisterRequest, opts ...grpc.CallOption) (*DeregisterResponse, error)
+	// Audit returns the worker's record of the searches it served, oldest
+	// first.
+	Audit(ctx context.Context, in *AuditRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AuditRecord], error)
 }
 
 type grepServiceClient struct {
@@ -56,11 +91,166 @@ func (c *grepServiceClient) Search(ctx context.Context, in *SearchRequest, opts
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type GrepService_SearchClient = grpc.ServerStreamingClient[SearchResponse]
 
+func (c *grepServiceClient) LinesAround(ctx context.Context, in *LinesAroundRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SearchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GrepService_ServiceDesc.Streams[1], GrepService_LinesAround_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LinesAroundRequest, SearchResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_LinesAroundClient = grpc.ServerStreamingClient[SearchResponse]
+
+func (c *grepServiceClient) ReadRange(ctx context.Context, in *ReadRangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GrepService_ServiceDesc.Streams[2], GrepService_ReadRange_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReadRangeRequest, FileChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_ReadRangeClient = grpc.ServerStreamingClient[FileChunk]
+
+func (c *grepServiceClient) ListFiles(ctx context.Context, in *ListFilesRequest, opts ...grpc.CallOption) (*ListFilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListFilesResponse)
+	err := c.cc.Invoke(ctx, GrepService_ListFiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grepServiceClient) Replicate(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ReplicaChunk, ReplicaAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GrepService_ServiceDesc.Streams[3], GrepService_Replicate_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReplicaChunk, ReplicaAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_ReplicateClient = grpc.ClientStreamingClient[ReplicaChunk, ReplicaAck]
+
+func (c *grepServiceClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingAck, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingAck)
+	err := c.cc.Invoke(ctx, GrepService_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grepServiceClient) PingReq(ctx context.Context, in *PingReqRequest, opts ...grpc.CallOption) (*PingAck, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingAck)
+	err := c.cc.Invoke(ctx, GrepService_PingReq_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grepServiceClient) Members(ctx context.Context, in *MembersRequest, opts ...grpc.CallOption) (*MembersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MembersResponse)
+	err := c.cc.Invoke(ctx, GrepService_Members_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grepServiceClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, GrepService_Register_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grepServiceClient) Deregister(ctx context.Context, in *DeregisterRequest, opts ...grpc.CallOption) (*DeregisterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeregisterResponse)
+	err := c.cc.Invoke(ctx, GrepService_Deregister_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grepServiceClient) Audit(ctx context.Context, in *AuditRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AuditRecord], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GrepService_ServiceDesc.Streams[4], GrepService_Audit_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AuditRequest, AuditRecord]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_AuditClient = grpc.ServerStreamingClient[AuditRecord]
+
 // GrepServiceServer is the server API for GrepService service.
 // All implementations must embed UnimplementedGrepServiceServer
 // for forward compatibility.
 type GrepServiceServer interface {
 	Search(*SearchRequest, grpc.ServerStreamingServer[SearchResponse]) error
+	// LinesAround returns the line at a byte offset reported by Search, as a
+	// MATCH, with CONTEXT lines around it.
+	LinesAround(*LinesAroundRequest, grpc.ServerStreamingServer[SearchResponse]) error
+	// ReadRange streams a range of lines or bytes of one of a shard's log
+	// files, decompressed.
+	ReadRange(*ReadRangeRequest, grpc.ServerStreamingServer[FileChunk]) error
+	// ListFiles describes the files a search of a shard would read.
+	ListFiles(context.Context, *ListFilesRequest) (*ListFilesResponse, error)
+	// Replicate stores copies of a peer's log files so they can be searched
+	// when that peer is down.
+	Replicate(grpc.ClientStreamingServer[ReplicaChunk, ReplicaAck]) error
+	// Ping, PingReq and Members make up the failure detector: workers probe
+	// each other directly or through a third worker, piggybacking what they
+	// know about the cluster, and report their view through Members.
+	Ping(context.Context, *PingRequest) (*PingAck, error)
+	PingReq(context.Context, *PingReqRequest) (*PingAck, error)
+	Members(context.Context, *MembersRequest) (*MembersResponse, error)
+	// Register announces a worker to the cluster through an introducer, one
+	// of the seed workers in cluster.properties; Deregister announces that it
+	// is leaving.
+	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	Deregister(context.Context, *DeregisterRequest) (*DeregisterResponse, error)
+	// Audit returns the worker's record of the searches it served, oldest
+	// first.
+	Audit(*AuditRequest, grpc.ServerStreamingServer[AuditRecord]) error
 	mustEmbedUnimplementedGrepServiceServer()
 }
 
@@ -74,6 +264,36 @@ type UnimplementedGrepServiceServer struct{}
 func (UnimplementedGrepServiceServer) Search(*SearchRequest, grpc.ServerStreamingServer[SearchResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method Search not implemented")
 }
+func (UnimplementedGrepServiceServer) LinesAround(*LinesAroundRequest, grpc.ServerStreamingServer[SearchResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method LinesAround not implemented")
+}
+func (UnimplementedGrepServiceServer) ReadRange(*ReadRangeRequest, grpc.ServerStreamingServer[FileChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ReadRange not implemented")
+}
+func (UnimplementedGrepServiceServer) ListFiles(context.Context, *ListFilesRequest) (*ListFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFiles not implemented")
+}
+func (UnimplementedGrepServiceServer) Replicate(grpc.ClientStreamingServer[ReplicaChunk, ReplicaAck]) error {
+	return status.Errorf(codes.Unimplemented, "method Replicate not implemented")
+}
+func (UnimplementedGrepServiceServer) Ping(context.Context, *PingRequest) (*PingAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedGrepServiceServer) PingReq(context.Context, *PingReqRequest) (*PingAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PingReq not implemented")
+}
+func (UnimplementedGrepServiceServer) Members(context.Context, *MembersRequest) (*MembersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Members not implemented")
+}
+func (UnimplementedGrepServiceServer) Register(context.Context, *RegisterRequest) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedGrepServiceServer) Deregister(context.Context, *DeregisterRequest) (*DeregisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deregister not implemented")
+}
+func (UnimplementedGrepServiceServer) Audit(*AuditRequest, grpc.ServerStreamingServer[AuditRecord]) error {
+	return status.Errorf(codes.Unimplemented, "method Audit not implemented")
+}
 func (UnimplementedGrepServiceServer) mustEmbedUnimplementedGrepServiceServer() {}
 func (UnimplementedGrepServiceServer) testEmbeddedByValue()                     {}
 
@@ -106,19 +326,212 @@ func _GrepService_Search_Handler(srv interface{}, stream grpc.ServerStream) erro
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type GrepService_SearchServer = grpc.ServerStreamingServer[SearchResponse]
 
+func _GrepService_LinesAround_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LinesAroundRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GrepServiceServer).LinesAround(m, &grpc.GenericServerStream[LinesAroundRequest, SearchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_LinesAroundServer = grpc.ServerStreamingServer[SearchResponse]
+
+func _GrepService_ReadRange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GrepServiceServer).ReadRange(m, &grpc.GenericServerStream[ReadRangeRequest, FileChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_ReadRangeServer = grpc.ServerStreamingServer[FileChunk]
+
+func _GrepService_ListFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrepServiceServer).ListFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrepService_ListFiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrepServiceServer).ListFiles(ctx, req.(*ListFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrepService_Replicate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GrepServiceServer).Replicate(&grpc.GenericServerStream[ReplicaChunk, ReplicaAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_ReplicateServer = grpc.ClientStreamingServer[ReplicaChunk, ReplicaAck]
+
+func _GrepService_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrepServiceServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrepService_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrepServiceServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrepService_PingReq_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingReqRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrepServiceServer).PingReq(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrepService_PingReq_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrepServiceServer).PingReq(ctx, req.(*PingReqRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrepService_Members_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrepServiceServer).Members(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrepService_Members_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrepServiceServer).Members(ctx, req.(*MembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrepService_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrepServiceServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrepService_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrepServiceServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrepService_Deregister_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeregisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrepServiceServer).Deregister(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GrepService_Deregister_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrepServiceServer).Deregister(ctx, req.(*DeregisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GrepService_Audit_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AuditRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GrepServiceServer).Audit(m, &grpc.GenericServerStream[AuditRequest, AuditRecord]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GrepService_AuditServer = grpc.ServerStreamingServer[AuditRecord]
+
 // GrepService_ServiceDesc is the grpc.ServiceDesc for GrepService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var GrepService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "grep.GrepService",
 	HandlerType: (*GrepServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListFiles",
+			Handler:    _GrepService_ListFiles_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _GrepService_Ping_Handler,
+		},
+		{
+			MethodName: "PingReq",
+			Handler:    _GrepService_PingReq_Handler,
+		},
+		{
+			MethodName: "Members",
+			Handler:    _GrepService_Members_Handler,
+		},
+		{
+			MethodName: "Register",
+			Handler:    _GrepService_Register_Handler,
+		},
+		{
+			MethodName: "Deregister",
+			Handler:    _GrepService_Deregister_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "Search",
 			Handler:       _GrepService_Search_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "LinesAround",
+			Handler:       _GrepService_LinesAround_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ReadRange",
+			Handler:       _GrepService_ReadRange_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Replicate",
+			Handler:       _GrepService_Replicate_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Audit",
+			Handler:       _GrepService_Audit_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "grep.proto",
 }
@@ -0,0 +1,202 @@
+package replica
+
+import (
+	grep "MP1/protoBuilds"
+	"MP1/search"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// chunkSize is the most file data sent in one ReplicaChunk.
+const chunkSize = 1 << 20
+
+// Pusher periodically ships new data in a worker's log files to the peers
+// holding its replicas.
+type Pusher struct {
+	Shard    string   // this worker's shard name
+	Peers    []string // addresses of the replica holders
+	LogDir   string
+	Glob     string
+	Rotated  bool
+	Interval time.Duration
+	// DialOptions are added to the options used for every peer.
+	DialOptions []grpc.DialOption
+	// Logf reports progress and errors; it defaults to discarding them.
+	Logf func(format string, args ...any)
+
+	remote map[string]*grep.ReplicaAck // what each peer holds, by address
+}
+
+// Run pushes until ctx is done.
+func (p *Pusher) Run(ctx context.Context) {
+	if p.Logf == nil {
+		p.Logf = func(string, ...any) {}
+	}
+	p.remote = map[string]*grep.ReplicaAck{}
+	t := time.NewTicker(p.Interval)
+	defer t.Stop()
+	for {
+		for _, peer := range p.Peers {
+			if err := p.push(ctx, peer); err != nil && ctx.Err() == nil {
+				p.Logf("replicate to %s: %v", peer, err)
+				// Ask for the peer's sizes again next round.
+				delete(p.remote, peer)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (p *Pusher) push(ctx context.Context, peer string) error {
+	// The first push of a large log can take several intervals.
+	ctx, cancel := context.WithTimeout(ctx, 10*p.Interval)
+	defer cancel()
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, p.DialOptions...)
+	conn, err := grpc.NewClient(peer, opts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	cli := grep.NewGrepServiceClient(conn)
+
+	if _, ok := p.remote[peer]; !ok {
+		// Learn what the peer already holds so a restart does not resend
+		// everything.
+		ack, err := p.send(ctx, cli, nil, nil)
+		if err != nil {
+			return err
+		}
+		p.remote[peer] = ack
+	}
+	held := p.remote[peer].GetSizes()
+	files, err := search.Files(p.LogDir, p.Glob, p.Rotated)
+	if err != nil {
+		return err
+	}
+	type part struct {
+		f    *os.File
+		name string
+		off  int64
+		size int64
+	}
+	var parts []part
+	present := &grep.ReplicaFiles{Glob: p.Glob, Rotated: p.Rotated}
+	defer func() {
+		for _, pt := range parts {
+			pt.f.Close()
+		}
+	}()
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			continue
+		}
+		name := filepath.Base(path)
+		present.Names = append(present.Names, name)
+		off, ok := held[name]
+		if ok && off <= fi.Size() {
+			// Logrotate shifts names, so the peer may hold a name whose
+			// file here is now another one, and possibly a larger one.
+			if h, err := head(f, off); err != nil || !bytes.Equal(h, p.remote[peer].Heads[name]) {
+				ok = false
+			}
+		}
+		if ok && off == fi.Size() {
+			f.Close()
+			continue
+		}
+		if !ok || off > fi.Size() {
+			// New, truncated, or another file since last time.
+			off = 0
+		}
+		parts = append(parts, part{f, name, off, fi.Size()})
+	}
+	gone := len(held)
+	for _, name := range present.Names {
+		if _, ok := held[name]; ok {
+			gone--
+		}
+	}
+	if len(parts) == 0 && gone == 0 {
+		return nil
+	}
+	ack, err := p.send(ctx, cli, present, func(emit func(*grep.ReplicaChunk) error) error {
+		buf := make([]byte, chunkSize)
+		for _, pt := range parts {
+			off := pt.off
+			if pt.size == 0 {
+				// Still create (or truncate) the replica of an empty file.
+				if err := emit(&grep.ReplicaChunk{Shard: p.Shard, FileName: pt.name}); err != nil {
+					return err
+				}
+			}
+			for off < pt.size {
+				n, err := pt.f.ReadAt(buf[:min(int64(len(buf)), pt.size-off)], off)
+				if n > 0 {
+					if err := emit(&grep.ReplicaChunk{Shard: p.Shard, FileName: pt.name, Offset: off, Data: buf[:n]}); err != nil {
+						return err
+					}
+					off += int64(n)
+				}
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.remote[peer] = ack
+	if len(parts) > 0 {
+		p.Logf("replicated %d file(s) to %s", len(parts), peer)
+	}
+	if gone > 0 {
+		p.Logf("removed %d file(s) gone from %s", gone, peer)
+	}
+	return nil
+}
+
+// send runs one Replicate call. The first chunk only names the shard and,
+// unless present is nil, the files the peer should keep, so a call with no
+// file data just prunes and returns what the peer holds.
+func (p *Pusher) send(ctx context.Context, cli grep.GrepServiceClient, present *grep.ReplicaFiles, body func(emit func(*grep.ReplicaChunk) error) error) (*grep.ReplicaAck, error) {
+	stream, err := cli.Replicate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(&grep.ReplicaChunk{Shard: p.Shard, Present: present}); err != nil {
+		return nil, err
+	}
+	if body != nil {
+		if err := body(stream.Send); err != nil {
+			return nil, fmt.Errorf("send: %w", err)
+		}
+	}
+	ack, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	return ack, nil
+}
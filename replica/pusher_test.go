@@ -0,0 +1,136 @@
+package replica
+
+import (
+	grep "MP1/protoBuilds"
+	"MP1/search"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// storeServer serves only Replicate, into a Store.
+type storeServer struct {
+	grep.UnimplementedGrepServiceServer
+	store *Store
+}
+
+func (s storeServer) Replicate(stream grep.GrepService_ReplicateServer) error {
+	return s.store.Receive(stream)
+}
+
+// replicaPeer starts an in-memory server for store and returns a Pusher
+// set up to push dir to it.
+func replicaPeer(t *testing.T, store *Store, dir string) *Pusher {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	grep.RegisterGrepServiceServer(srv, storeServer{store: store})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return &Pusher{
+		Shard:    "vm1",
+		Peers:    []string{"passthrough:///bufnet"},
+		LogDir:   dir,
+		Glob:     "vm1.log",
+		Rotated:  true,
+		Interval: time.Second,
+		DialOptions: []grpc.DialOption{grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		})},
+		Logf:   t.Logf,
+		remote: map[string]*grep.ReplicaAck{},
+	}
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestPushResendsFilesRotatedUnderTheSameName(t *testing.T) {
+	src := t.TempDir()
+	store := &Store{Dir: t.TempDir()}
+	p := replicaPeer(t, store, src)
+	write := func(name string, data []byte) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(src, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	push := func() {
+		t.Helper()
+		if err := p.push(context.Background(), p.Peers[0]); err != nil {
+			t.Fatal(err)
+		}
+		dst, err := store.ShardDir(p.Shard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := filepath.Glob(filepath.Join(src, "*"))
+		got, _ := filepath.Glob(filepath.Join(dst, "*"))
+		if len(got) != len(want) {
+			t.Fatalf("replica holds %d files, want %d", len(got), len(want))
+		}
+		for _, path := range want {
+			w, _ := os.ReadFile(path)
+			g, err := os.ReadFile(filepath.Join(dst, filepath.Base(path)))
+			if err != nil || !bytes.Equal(g, w) {
+				t.Fatalf("replica of %s = %q, %v; want %q", filepath.Base(path), g, err, w)
+			}
+		}
+	}
+
+	write("vm1.log", []byte("day one\n"))
+	push()
+
+	// The log grows and is appended.
+	write("vm1.log", []byte("day one\nmore of day one\n"))
+	push()
+
+	// logrotate: vm1.log moves to vm1.log.1 and a new, longer day starts.
+	// The replica's vm1.log.1 is new, but its vm1.log is shorter than the
+	// file now under that name and must not just be appended to.
+	day1 := []byte("day one\nmore of day one\n")
+	write("vm1.log.1", day1)
+	write("vm1.log", []byte("day two is longer than the first day\n"))
+	push()
+
+	// The next rotation compresses: vm1.log.1 moves to vm1.log.2.gz.
+	write("vm1.log.2.gz", gzipped(t, day1))
+	write("vm1.log.1", []byte("day two is longer than the first day\n"))
+	write("vm1.log", []byte("day three is longer still than the day before\n"))
+	push()
+
+	// The holder searches its copies as the primary does: oldest first.
+	dst, _ := store.ShardDir(p.Shard)
+	glob, rotated, err := store.Source(p.Shard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := search.Files(dst, glob, rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	if want := []string{"vm1.log.2.gz", "vm1.log.1", "vm1.log"}; !slices.Equal(names, want) {
+		t.Fatalf("replica files = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,261 @@
+// Package replica copies each worker's log files to its successors in the
+// cluster ring, so a shard can still be searched when its primary is down.
+package replica
+
+import (
+	grep "MP1/protoBuilds"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store keeps the replica files a worker holds for its peers, one
+// directory per shard under Dir.
+type Store struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+// validName rejects names that could escape the store directory.
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// ShardDir returns the directory holding shard's replica files.
+func (s *Store) ShardDir(shard string) (string, error) {
+	// Names starting with a dot are kept for the files beside the shard
+	// directories.
+	if !validName(shard) || strings.HasPrefix(shard, ".") {
+		return "", fmt.Errorf("invalid shard name %q", shard)
+	}
+	return filepath.Join(s.Dir, shard), nil
+}
+
+// Receive applies the chunks of one Replicate call. A chunk listing the
+// files present at the primary deletes the replicas of any others, so
+// files removed or rotated away there go here too. A chunk at offset 0
+// rewrites its file and a chunk at the file's current size appends to it;
+// any other chunk is out of step and skipped. The ack reports the real
+// sizes and heads so the sender can resume from the right place, or start
+// over on a file that has changed under the same name.
+func (s *Store) Receive(stream grep.GrepService_ReplicateServer) error {
+	shard := ""
+	for {
+		c, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if shard == "" {
+			shard = c.Shard
+		} else if c.Shard != shard {
+			return fmt.Errorf("chunks for shards %q and %q in one stream", shard, c.Shard)
+		}
+		if c.Present != nil {
+			if err := s.prune(shard, c.Present.Names); err != nil {
+				return err
+			}
+			if err := s.setSource(shard, c.Present.Glob, c.Present.Rotated); err != nil {
+				return err
+			}
+		}
+		if c.FileName == "" {
+			continue
+		}
+		if err := s.apply(c); err != nil {
+			return err
+		}
+	}
+	ack, err := s.state(shard)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(ack)
+}
+
+func (s *Store) apply(c *grep.ReplicaChunk) error {
+	dir, err := s.ShardDir(c.Shard)
+	if err != nil {
+		return err
+	}
+	if !validName(c.FileName) {
+		return fmt.Errorf("invalid file name %q", c.FileName)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, c.FileName)
+	flags := os.O_WRONLY | os.O_CREATE
+	if c.Offset == 0 {
+		flags |= os.O_TRUNC
+	} else {
+		fi, err := os.Stat(path)
+		if err != nil || fi.Size() != c.Offset {
+			return nil
+		}
+		flags |= os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(c.Data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// source is how the primary picks the files to search.
+type source struct {
+	Glob    string `json:"glob"`
+	Rotated bool   `json:"rotated"`
+}
+
+// sourcePath is where shard's source is kept, next to its directory.
+func (s *Store) sourcePath(shard string) string {
+	return filepath.Join(s.Dir, "."+shard+".source")
+}
+
+func (s *Store) setSource(shard, glob string, rotated bool) error {
+	if glob == "" {
+		// A primary that does not send its glob.
+		return nil
+	}
+	if _, err := s.ShardDir(shard); err != nil {
+		return err
+	}
+	data, err := json.Marshal(source{glob, rotated})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, err := os.ReadFile(s.sourcePath(shard)); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+	tmp := s.sourcePath(shard) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.sourcePath(shard))
+}
+
+// Source returns the glob and rotated flag the primary of shard searches
+// its logs with, so its replicas are searched the same way. Until the
+// primary has said, every file matches and none is taken as rotated.
+func (s *Store) Source(shard string) (glob string, rotated bool, err error) {
+	if _, err := s.ShardDir(shard); err != nil {
+		return "", false, err
+	}
+	s.mu.Lock()
+	data, err := os.ReadFile(s.sourcePath(shard))
+	s.mu.Unlock()
+	if os.IsNotExist(err) {
+		return "*", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	var src source
+	if err := json.Unmarshal(data, &src); err != nil {
+		return "", false, fmt.Errorf("%s: %v", s.sourcePath(shard), err)
+	}
+	return src.Glob, src.Rotated, nil
+}
+
+// prune deletes shard's replica files that are not named in keep.
+func (s *Store) prune(shard string, keep []string) error {
+	dir, err := s.ShardDir(shard)
+	if err != nil {
+		return err
+	}
+	present := make(map[string]bool, len(keep))
+	for _, name := range keep {
+		present[name] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Type().IsRegular() && !present[e.Name()] {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// headSize is how much of the start of a file identifies it. Rotation
+// moves a file to another name, and the file then there starts
+// differently, whatever its size.
+const headSize = 4096
+
+// head returns the SHA-256 of the first headSize bytes of the size bytes
+// of r.
+func head(r io.ReaderAt, size int64) ([]byte, error) {
+	buf := make([]byte, min(size, headSize))
+	if _, err := r.ReadAt(buf, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	sum := sha256.Sum256(buf)
+	return sum[:], nil
+}
+
+// state returns the size and head of every replica file held for shard.
+func (s *Store) state(shard string) (*grep.ReplicaAck, error) {
+	dir, err := s.ShardDir(shard)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ack := &grep.ReplicaAck{Sizes: map[string]int64{}, Heads: map[string][]byte{}}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return ack, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		fi, err := f.Stat()
+		var h []byte
+		if err == nil {
+			h, err = head(f, fi.Size())
+		}
+		f.Close()
+		if err != nil {
+			continue
+		}
+		ack.Sizes[e.Name()], ack.Heads[e.Name()] = fi.Size(), h
+	}
+	return ack, nil
+}
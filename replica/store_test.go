@@ -0,0 +1,96 @@
+package replica
+
+import (
+	grep "MP1/protoBuilds"
+	"io"
+	"maps"
+	"testing"
+)
+
+// fakeStream plays a Replicate call's chunks to a Store.
+type fakeStream struct {
+	grep.GrepService_ReplicateServer
+	chunks []*grep.ReplicaChunk
+	ack    *grep.ReplicaAck
+}
+
+func (f *fakeStream) Recv() (*grep.ReplicaChunk, error) {
+	if len(f.chunks) == 0 {
+		return nil, io.EOF
+	}
+	c := f.chunks[0]
+	f.chunks = f.chunks[1:]
+	return c, nil
+}
+
+func (f *fakeStream) SendAndClose(a *grep.ReplicaAck) error {
+	f.ack = a
+	return nil
+}
+
+func receive(t *testing.T, s *Store, chunks ...*grep.ReplicaChunk) map[string]int64 {
+	t.Helper()
+	f := &fakeStream{chunks: chunks}
+	if err := s.Receive(f); err != nil {
+		t.Fatal(err)
+	}
+	return f.ack.Sizes
+}
+
+func TestReceivePrunesFilesGoneFromPrimary(t *testing.T) {
+	s := &Store{Dir: t.TempDir()}
+	got := receive(t, s,
+		&grep.ReplicaChunk{Shard: "vm1", Present: &grep.ReplicaFiles{Names: []string{"vm1.log", "vm1.log.1", "vm1.log.2.gz"}}},
+		&grep.ReplicaChunk{Shard: "vm1", FileName: "vm1.log", Data: []byte("new\n")},
+		&grep.ReplicaChunk{Shard: "vm1", FileName: "vm1.log.1", Data: []byte("older\n")},
+		&grep.ReplicaChunk{Shard: "vm1", FileName: "vm1.log.2.gz", Data: []byte("oldest")},
+	)
+	if want := map[string]int64{"vm1.log": 4, "vm1.log.1": 6, "vm1.log.2.gz": 6}; !maps.Equal(got, want) {
+		t.Fatalf("sizes = %v, want %v", got, want)
+	}
+
+	// A sizes-only call lists nothing and must not delete anything.
+	if got := receive(t, s, &grep.ReplicaChunk{Shard: "vm1"}); len(got) != 3 {
+		t.Fatalf("sizes after a query = %v, want all 3 files", got)
+	}
+
+	// The primary rotated: vm1.log.2.gz aged out, the others moved up.
+	got = receive(t, s,
+		&grep.ReplicaChunk{Shard: "vm1", Present: &grep.ReplicaFiles{Names: []string{"vm1.log", "vm1.log.1", "vm1.log.2.gz"}}},
+		&grep.ReplicaChunk{Shard: "vm1", FileName: "vm1.log", Data: []byte("")},
+		&grep.ReplicaChunk{Shard: "vm1", FileName: "vm1.log.1", Data: []byte("new\n")},
+		&grep.ReplicaChunk{Shard: "vm1", FileName: "vm1.log.2.gz", Data: []byte("older\n")},
+	)
+	if want := map[string]int64{"vm1.log": 0, "vm1.log.1": 4, "vm1.log.2.gz": 6}; !maps.Equal(got, want) {
+		t.Fatalf("sizes after rotation = %v, want %v", got, want)
+	}
+
+	// The primary's logs were deleted.
+	got = receive(t, s, &grep.ReplicaChunk{Shard: "vm1", Present: &grep.ReplicaFiles{Names: []string{"vm1.log"}}})
+	if want := map[string]int64{"vm1.log": 0}; !maps.Equal(got, want) {
+		t.Fatalf("sizes after removal = %v, want %v", got, want)
+	}
+	got = receive(t, s, &grep.ReplicaChunk{Shard: "vm1", Present: &grep.ReplicaFiles{}})
+	if len(got) != 0 {
+		t.Fatalf("sizes with no files at the primary = %v, want none", got)
+	}
+}
+
+func TestReceiveKeepsPrimarySource(t *testing.T) {
+	s := &Store{Dir: t.TempDir()}
+	if glob, rotated, err := s.Source("vm1"); err != nil || glob != "*" || rotated {
+		t.Fatalf("Source before any push = %q, %v, %v; want *, false", glob, rotated, err)
+	}
+	receive(t, s, &grep.ReplicaChunk{Shard: "vm1", Present: &grep.ReplicaFiles{Glob: "vm1.log", Rotated: true}})
+	if glob, rotated, err := s.Source("vm1"); err != nil || glob != "vm1.log" || !rotated {
+		t.Fatalf("Source = %q, %v, %v; want vm1.log, true", glob, rotated, err)
+	}
+	// A sizes-only call leaves it alone.
+	receive(t, s, &grep.ReplicaChunk{Shard: "vm1"})
+	if glob, _, _ := s.Source("vm1"); glob != "vm1.log" {
+		t.Fatalf("Source after a query = %q, want vm1.log", glob)
+	}
+	if _, err := s.ShardDir(".vm1.source"); err == nil {
+		t.Fatal("ShardDir accepted a name starting with a dot")
+	}
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// GroupBy says what an aggregation groups selected lines by: a capture
+// group of the pattern or a field of the line.
+type GroupBy struct {
+	Field     bool   // group by a field of the line rather than a capture group
+	Index     int    // capture group, 0 for the whole match, or field from 1, 0 for the whole line
+	Name      string // named capture group, used instead of Index
+	Separator string // field separator; empty splits on runs of blanks, as awk does
+}
+
+// Keyer returns a function that extracts g's key from a whole line
+// selected by s, so s must not be set up for -o or context lines. The
+// function reports false for lines without a key, such as those where the
+// capture group took no part in the match or that have too few fields.
+func (s *Searcher) Keyer(g GroupBy) (func(line []byte) (string, bool), error) {
+	if s.opts.OnlyMatching || s.opts.Count || s.opts.BeforeContext > 0 || s.opts.AfterContext > 0 {
+		return nil, fmt.Errorf("aggregation cannot be combined with -o, -c or context lines")
+	}
+	if g.Index < 0 {
+		return nil, fmt.Errorf("group-by index must not be negative")
+	}
+	if g.Field {
+		return fieldKeyer(g.Index, g.Separator), nil
+	}
+	if s.opts.Invert {
+		return nil, fmt.Errorf("cannot group by a capture group with -v")
+	}
+	i := g.Index
+	if g.Name != "" {
+		if i = s.m.groupIndex(g.Name); i < 0 {
+			return nil, fmt.Errorf("no capture group named %q", g.Name)
+		}
+	}
+	if i > s.m.groups() {
+		return nil, fmt.Errorf("the patterns have %d capture groups, not %d", s.m.groups(), i)
+	}
+	return func(line []byte) (string, bool) {
+		b, ok := s.m.group(line, i)
+		return string(b), ok
+	}, nil
+}
+
+func fieldKeyer(n int, sep string) func([]byte) (string, bool) {
+	return func(line []byte) (string, bool) {
+		if n == 0 {
+			return string(line), true
+		}
+		var fields [][]byte
+		if sep == "" {
+			fields = bytes.Fields(line)
+		} else {
+			fields = bytes.Split(line, []byte(sep))
+		}
+		if n > len(fields) {
+			return "", false
+		}
+		return string(fields[n-1]), true
+	}
+}
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+)
+
+// LinesAround reads r, a whole file, and calls fn for the line containing
+// byte offset and for up to before lines ahead of it and after lines
+// behind it. The line at offset is reported as a selected line and the
+// others as context. It returns io.ErrUnexpectedEOF if the file is shorter
+// than offset.
+func LinesAround(ctx context.Context, r io.Reader, offset int64, before, after int, fn func(Hit) error) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	var pending []Hit // the last `before` lines ahead of offset
+	var n, off int64
+	found := false
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			n++
+			start := off
+			off += int64(len(line))
+			if n%4096 == 0 {
+				if cerr := ctx.Err(); cerr != nil {
+					return cerr
+				}
+			}
+			h := Hit{Line: n, Offset: start, Text: string(bytes.TrimSuffix(line, []byte{'\n'})), Context: true}
+			switch {
+			case found:
+				if err := fn(h); err != nil {
+					return err
+				}
+				if after--; after <= 0 {
+					return nil
+				}
+			case off > offset:
+				for _, p := range pending {
+					if err := fn(p); err != nil {
+						return err
+					}
+				}
+				h.Context = false
+				if err := fn(h); err != nil {
+					return err
+				}
+				if after <= 0 {
+					return nil
+				}
+				found = true
+			case before > 0:
+				if len(pending) == before {
+					pending = append(pending[:0], pending[1:]...)
+				}
+				pending = append(pending, h)
+			}
+		}
+		if err == io.EOF {
+			if !found {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Lines reads r, a whole file, and calls fn for lines first through last,
+// numbered from 1, or through the end of the file when last is 0. It
+// returns io.ErrUnexpectedEOF if the file has fewer than first lines.
+func Lines(ctx context.Context, r io.Reader, first, last int64, fn func(Hit) error) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	var n, off int64
+	for last == 0 || n < last {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			n++
+			start := off
+			off += int64(len(line))
+			if n%4096 == 0 {
+				if cerr := ctx.Err(); cerr != nil {
+					return cerr
+				}
+			}
+			if n >= first {
+				h := Hit{Line: n, Offset: start, Text: string(bytes.TrimSuffix(line, []byte{'\n'}))}
+				if err := fn(h); err != nil {
+					return err
+				}
+			}
+		}
+		if err == io.EOF {
+			if n < first {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
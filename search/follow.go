@@ -0,0 +1,147 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// Follower tails a log file by name, like tail -F. It survives the file
+// being rotated (renamed or removed and recreated) or truncated in place.
+// Each incarnation of the file is read through its own reader from Next,
+// so a Searcher numbers lines afresh after a rotation.
+type Follower struct {
+	path      string
+	poll      time.Duration
+	fromStart bool // read the first incarnation from its start, not its end
+
+	f   *os.File
+	fi  os.FileInfo // f's identity, for spotting rotation
+	off int64
+}
+
+// Follow returns a Follower for path that checks for new data every poll.
+// With fromStart unset, data already in the file is skipped.
+func Follow(path string, poll time.Duration, fromStart bool) *Follower {
+	return &Follower{path: path, poll: poll, fromStart: fromStart}
+}
+
+// Next waits for the next incarnation of the file and returns a reader of
+// its data along with the number of lines and bytes before the reader's
+// first byte.
+// The reader blocks for appended data and returns io.EOF once the file has
+// been rotated or truncated and everything written before that is read.
+// Next and the reader return ctx's error when it is done.
+func (fl *Follower) Next(ctx context.Context) (io.Reader, int64, int64, error) {
+	fl.Close()
+	for {
+		f, err := os.Open(fl.path)
+		if err == nil {
+			fi, err := f.Stat()
+			if err == nil {
+				fl.f, fl.fi, fl.off = f, fi, 0
+				break
+			}
+			f.Close()
+		}
+		if err := fl.wait(ctx); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+	var lines int64
+	if !fl.fromStart {
+		// Start at the end, counting the lines skipped so line numbers
+		// stay true to the file.
+		var err error
+		if lines, fl.off, err = countLines(fl.f); err != nil {
+			fl.Close()
+			return nil, 0, 0, err
+		}
+	}
+	fl.fromStart = true // later incarnations are new files
+	return &followReader{fl: fl, ctx: ctx}, lines, fl.off, nil
+}
+
+// Close releases the file being followed.
+func (fl *Follower) Close() error {
+	if fl.f == nil {
+		return nil
+	}
+	err := fl.f.Close()
+	fl.f = nil
+	return err
+}
+
+func (fl *Follower) wait(ctx context.Context) error {
+	t := time.NewTimer(fl.poll)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// ended reports whether the file at path is no longer the one being read,
+// or has been truncated below what was read.
+func (fl *Follower) ended() bool {
+	if fi, err := os.Stat(fl.path); err == nil && !os.SameFile(fi, fl.fi) {
+		return true
+	}
+	fi, err := fl.f.Stat()
+	return err != nil || fi.Size() < fl.off
+}
+
+type followReader struct {
+	fl  *Follower
+	ctx context.Context
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	fl := r.fl
+	for {
+		if fl.f == nil {
+			return 0, io.EOF
+		}
+		n, err := fl.f.ReadAt(p, fl.off)
+		if n > 0 {
+			fl.off += int64(n)
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		// Caught up. A rotated file may still be written to for a moment,
+		// so it is only left once it has stopped growing.
+		if fl.ended() {
+			return 0, io.EOF
+		}
+		if err := fl.wait(r.ctx); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// countLines returns the number of complete lines in f and the offset just
+// past the last of them, so a line still being written is read whole later.
+func countLines(f *os.File) (int64, int64, error) {
+	var lines, off, end int64
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := f.Read(buf)
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+			end = off + int64(i) + 1
+		}
+		off += int64(n)
+		if err == io.EOF {
+			return lines, end, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Matcher decides whether a line is selected and where the matches are.
+type Matcher struct {
+	re     *regexp.Regexp
+	invert bool
+	word   bool
+}
+
+// Compile builds a Matcher from parsed options.
+func Compile(o Options) (*Matcher, error) {
+	alts := make([]string, 0, len(o.Patterns))
+	for _, p := range o.Patterns {
+		switch o.Syntax {
+		case Fixed:
+			p = regexp.QuoteMeta(p)
+		case Basic:
+			p = basicToExtended(p)
+		}
+		alts = append(alts, "(?:"+p+")")
+	}
+	expr := strings.Join(alts, "|")
+	word := o.WholeWord && !o.LineRegexp
+	switch {
+	case o.LineRegexp:
+		// As with grep, -x wins over -w.
+		expr = `^(?:` + expr + `)$`
+	case word:
+		// Go regexps have no lookaround, so the word boundaries are matched
+		// explicitly and the pattern itself is captured as group 1.
+		expr = `(?:^|\W)(` + expr + `)(?:\W|$)`
+	}
+	if o.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if o.Syntax != Perl {
+		// POSIX grep reports the leftmost-longest match.
+		re.Longest()
+	}
+	return &Matcher{re: re, invert: o.Invert, word: word}, nil
+}
+
+// Match reports whether line is selected, taking -v into account.
+func (m *Matcher) Match(line []byte) bool {
+	return m.re.Match(line) != m.invert
+}
+
+// FindAll returns the [start, end) offsets of every match in line. It
+// returns nil for inverted matchers, mirroring grep -v -o.
+func (m *Matcher) FindAll(line []byte) [][]int {
+	if m.invert {
+		return nil
+	}
+	if !m.word {
+		return m.re.FindAllIndex(line, -1)
+	}
+	var out [][]int
+	for pos := 0; pos <= len(line); {
+		loc := m.re.FindSubmatchIndex(line[pos:])
+		if loc == nil {
+			break
+		}
+		if loc[2] == 0 && pos > 0 && isWordByte(line[pos-1]) {
+			// "^" matched at the slice start, which is not a line start.
+			pos++
+			continue
+		}
+		out = append(out, []int{pos + loc[2], pos + loc[3]})
+		// Resume right after the word so a separator shared by two
+		// adjacent words is not consumed by the first one.
+		next := pos + loc[3]
+		if next <= pos {
+			next = pos + 1
+		}
+		pos = next
+	}
+	return out
+}
+
+// shift is how many groups Compile adds ahead of the patterns' own.
+func (m *Matcher) shift() int {
+	if m.word {
+		return 1
+	}
+	return 0
+}
+
+// groups returns the number of capture groups in the patterns. With
+// several patterns their groups are numbered in order across them.
+func (m *Matcher) groups() int {
+	return m.re.NumSubexp() - m.shift()
+}
+
+// groupIndex returns the number of the capture group called name, or -1.
+func (m *Matcher) groupIndex(name string) int {
+	i := m.re.SubexpIndex(name)
+	if i < 0 {
+		return -1
+	}
+	return i - m.shift()
+}
+
+// group returns capture group i of the first match in line, 0 being the
+// whole match. It reports false if the line does not match or the group
+// took no part in the match.
+func (m *Matcher) group(line []byte, i int) ([]byte, bool) {
+	loc := m.re.FindSubmatchIndex(line)
+	i += m.shift()
+	if loc == nil || loc[2*i] < 0 {
+		return nil, false
+	}
+	return line[loc[2*i]:loc[2*i+1]], true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// basicToExtended rewrites a POSIX basic regexp into the syntax understood
+// by package regexp: \( \) \{ \} \| \+ \? become operators and their bare
+// forms become literals.
+func basicToExtended(p string) string {
+	var b strings.Builder
+	atStart := true
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case c == '\\' && i+1 < len(p):
+			i++
+			switch n := p[i]; n {
+			case '(', ')', '{', '}', '|', '+', '?':
+				b.WriteByte(n)
+				atStart = n == '(' || n == '|'
+				continue
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(n)
+			}
+		case c == '[':
+			j := bracketEnd(p, i)
+			b.WriteString(p[i:j])
+			i = j - 1
+		case c == '(' || c == ')' || c == '{' || c == '}' || c == '|' || c == '+' || c == '?':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '*' && atStart:
+			b.WriteString(`\*`)
+		case c == '^' && atStart:
+			b.WriteByte(c)
+			continue
+		default:
+			b.WriteByte(c)
+		}
+		atStart = false
+	}
+	return b.String()
+}
+
+// bracketEnd returns the index just past the bracket expression starting at
+// p[i], or len(p) if it is not terminated.
+func bracketEnd(p string, i int) int {
+	j := i + 1
+	if j < len(p) && p[j] == '^' {
+		j++
+	}
+	if j < len(p) && p[j] == ']' {
+		j++
+	}
+	for j < len(p) {
+		switch {
+		case p[j] == '[' && j+1 < len(p) && (p[j+1] == ':' || p[j+1] == '.' || p[j+1] == '='):
+			end := strings.Index(p[j+2:], string(p[j+1])+"]")
+			if end < 0 {
+				return len(p)
+			}
+			j += end + 4
+		case p[j] == ']':
+			return j + 1
+		default:
+			j++
+		}
+	}
+	return len(p)
+}
@@ -0,0 +1,132 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+// The expectations in this file are what GNU grep 3.8 prints for the same
+// arguments and line.
+
+func TestBasicToExtended(t *testing.T) {
+	for _, tc := range []struct{ bre, want string }{
+		{`a\(b\)c`, `a(b)c`},
+		{`a(b)`, `a\(b\)`},
+		{`ab\{2,3\}`, `ab{2,3}`},
+		{`ab{2}`, `ab\{2\}`},
+		{`cat\|dog`, `cat|dog`},
+		{`cat|dog`, `cat\|dog`},
+		{`ab\+`, `ab+`},
+		{`ab+`, `ab\+`},
+		{`colou\?r`, `colou?r`},
+		{`a?`, `a\?`},
+		{`*star`, `\*star`},
+		{`^*star`, `^\*star`},
+		{`\(*x\)`, `(\*x)`},
+		{`a\|*b`, `a|\*b`},
+		{`x*`, `x*`},
+		{`[(|)]`, `[(|)]`},
+		{`[]a]`, `[]a]`},
+		{`[^]a]+`, `[^]a]\+`},
+		{`[[:digit:]+]`, `[[:digit:]+]`},
+		{`a\.c`, `a\.c`},
+		{`\\(`, `\\\(`},
+	} {
+		if got := basicToExtended(tc.bre); got != tc.want {
+			t.Errorf("basicToExtended(%q) = %q, want %q", tc.bre, got, tc.want)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	for _, tc := range []struct {
+		args  []string
+		line  string
+		match bool
+		only  []string // what -o prints
+	}{
+		// Basic syntax: escaped operators, literal bare ones.
+		{[]string{"a\\(b\\)c"}, "xabcx", true, []string{"abc"}},
+		{[]string{"a(b)"}, "a(b)", true, []string{"a(b)"}},
+		{[]string{"a(b)"}, "ab", false, nil},
+		{[]string{"ab\\{2\\}"}, "abbb", true, []string{"abb"}},
+		{[]string{"ab{2}"}, "ab{2}", true, []string{"ab{2}"}},
+		{[]string{"ab{2}"}, "abb", false, nil},
+		{[]string{"cat\\|dog"}, "hotdog", true, []string{"dog"}},
+		{[]string{"cat|dog"}, "cat|dog", true, []string{"cat|dog"}},
+		{[]string{"cat|dog"}, "dog", false, nil},
+		{[]string{"ab\\+"}, "abbbc", true, []string{"abbb"}},
+		{[]string{"ab+"}, "ab+", true, []string{"ab+"}},
+		{[]string{"ab+"}, "abb", false, nil},
+		{[]string{"colou\\?r"}, "color", true, []string{"color"}},
+		{[]string{"a?"}, "a?", true, []string{"a?"}},
+		{[]string{"*star"}, "a *star", true, []string{"*star"}},
+		{[]string{"^*star"}, "*star", true, []string{"*star"}},
+		{[]string{"\\(*x\\)"}, "a*x", true, []string{"*x"}},
+		{[]string{"x*"}, "xxx", true, []string{"xxx"}},
+		{[]string{"[(|)]"}, "f(x)", true, []string{"(", ")"}},
+		{[]string{"[]a]"}, "x]y", true, []string{"]"}},
+		{[]string{"[[:digit:]]\\+"}, "port 8080", true, []string{"8080"}},
+		{[]string{"a.c"}, "abc", true, []string{"abc"}},
+		{[]string{"a\\.c"}, "abc", false, nil},
+		{[]string{"\\(ab\\)*c"}, "ababc", true, []string{"ababc"}},
+		{[]string{"-E", "a(b)+"}, "abbb", true, []string{"abbb"}},
+		{[]string{"-E", "a\\(b\\)"}, "a(b)", true, []string{"a(b)"}},
+		{[]string{"-E", "cat|dog"}, "dog", true, []string{"dog"}},
+		// POSIX leftmost-longest, except with -P.
+		{[]string{"-E", "a|ab"}, "abc", true, []string{"ab"}},
+		{[]string{"-E", "x|xy|xyz"}, "xyz", true, []string{"xyz"}},
+		{[]string{"-e", "err", "-e", "error"}, "error: boom", true, []string{"error"}},
+		{[]string{"-E", "(a|ab)(c|bcd)"}, "abcd", true, []string{"abcd"}},
+		{[]string{"-P", "a|ab"}, "abc", true, []string{"a"}},
+		// -w needs non-word characters or the line ends around the match.
+		{[]string{"-w", "err"}, "err: x", true, []string{"err"}},
+		{[]string{"-w", "err"}, "error", false, nil},
+		{[]string{"-w", "err"}, "an err", true, []string{"err"}},
+		{[]string{"-w", "err"}, "x_err", false, nil},
+		{[]string{"-w", "err"}, "err err,err", true, []string{"err", "err", "err"}},
+		{[]string{"-w", "foo"}, "foobar foo", true, []string{"foo"}},
+		{[]string{"-w", "-E", "a+"}, "aa aaa b", true, []string{"aa", "aaa"}},
+		{[]string{"-w", "-i", "ERR"}, "Err err ERRor", true, []string{"Err", "err"}},
+		{[]string{"-w", "-F", "a.b"}, "xa.b a.b", true, []string{"a.b"}},
+		{[]string{"-w", "-v", "err"}, "error", true, nil},
+		{[]string{"-w", "-v", "err"}, "an err", false, nil},
+		// -i, -F and -x, alone and together; -x wins over -w.
+		{[]string{"-i", "error"}, "ERROR here", true, []string{"ERROR"}},
+		{[]string{"-i", "-F", "A.B"}, "xa.bx", true, []string{"a.b"}},
+		{[]string{"-F", "a.b"}, "axb", false, nil},
+		{[]string{"-F", "a\\(b"}, "a\\(b", true, []string{"a\\(b"}},
+		{[]string{"-F", "-e", "x", "-e", "y"}, "zyx", true, []string{"y", "x"}},
+		{[]string{"-x", "abc"}, "abc", true, []string{"abc"}},
+		{[]string{"-x", "abc"}, "abcd", false, nil},
+		{[]string{"-x", "-i", "abc"}, "ABC", true, []string{"ABC"}},
+		{[]string{"-x", "-F", "a.c"}, "a.c", true, []string{"a.c"}},
+		{[]string{"-x", "-F", "a.c"}, "abc", false, nil},
+		{[]string{"-x", "-F", "-i", "A.C"}, "a.c", true, []string{"a.c"}},
+		{[]string{"-x", "-E", "a|ab"}, "ab", true, []string{"ab"}},
+		{[]string{"-x", "-e", "ab", "-e", "abc"}, "abc", true, []string{"abc"}},
+		{[]string{"-x", "-w", "b"}, "a b", false, nil},
+		{[]string{"-x", "-v", "abc"}, "abcd", true, nil},
+		{[]string{"-i", "-v", "error"}, "Error", false, nil},
+		{[]string{"-F", "-w", "-i", "ERR"}, "err!", true, []string{"err"}},
+	} {
+		o, err := ParseArgs(tc.args)
+		if err != nil {
+			t.Fatalf("ParseArgs(%q): %v", tc.args, err)
+		}
+		m, err := Compile(o)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", tc.args, err)
+		}
+		if got := m.Match([]byte(tc.line)); got != tc.match {
+			t.Errorf("grep %q on %q: match = %v, want %v", tc.args, tc.line, got, tc.match)
+		}
+		var only []string
+		for _, loc := range m.FindAll([]byte(tc.line)) {
+			only = append(only, tc.line[loc[0]:loc[1]])
+		}
+		if !slices.Equal(only, tc.only) {
+			t.Errorf("grep -o %q on %q = %q, want %q", tc.args, tc.line, only, tc.only)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Compression names the format a log file is stored in.
+type Compression string
+
+const (
+	Plain Compression = "none"
+	Gzip  Compression = "gzip"
+	Zstd  Compression = "zstd"
+	Bzip2 Compression = "bzip2"
+	Xz    Compression = "xz"
+)
+
+var magics = []struct {
+	magic []byte
+	kind  Compression
+}{
+	{[]byte{0x1f, 0x8b}, Gzip},
+	{[]byte{0x28, 0xb5, 0x2f, 0xfd}, Zstd},
+	{[]byte("BZh"), Bzip2},
+	{[]byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, Xz},
+}
+
+// Open opens a log file for reading and decompresses it on the fly. The
+// format is detected from the file's leading bytes, not its name, so
+// rotated archives are handled whatever suffix logrotate gave them.
+func Open(path string) (io.ReadCloser, Compression, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	r, kind, err := decompress(f, f)
+	if err != nil {
+		return nil, kind, err
+	}
+	return r, kind, nil
+}
+
+// decompress reads f's data through raw, which may wrap f, and closes f
+// when the returned reader is closed.
+func decompress(f *os.File, raw io.Reader) (*logReader, Compression, error) {
+	br := bufio.NewReaderSize(raw, 64*1024)
+	head, _ := br.Peek(6)
+	kind := Plain
+	for _, m := range magics {
+		if bytes.HasPrefix(head, m.magic) {
+			kind = m.kind
+			break
+		}
+	}
+	var r io.Reader
+	closeFn := func() error { return f.Close() }
+	switch kind {
+	case Plain:
+		r = br
+	case Gzip:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, kind, err
+		}
+		r = zr
+	case Zstd:
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			f.Close()
+			return nil, kind, err
+		}
+		r = zr
+		closeFn = func() error { zr.Close(); return f.Close() }
+	case Bzip2:
+		r = bzip2.NewReader(br)
+	case Xz:
+		xr, err := xz.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, kind, err
+		}
+		r = xr
+	}
+	return &logReader{Reader: r, br: br, close: closeFn}, kind, nil
+}
+
+type logReader struct {
+	io.Reader
+	br    *bufio.Reader // the stored data, before decompression
+	close func() error
+}
+
+func (l *logReader) Close() error { return l.close() }
+
+// estimateSample is how much of a file, decompressed, EstimateLines reads.
+const estimateSample = 1 << 20
+
+// EstimateLines returns the number of lines in a log file, whether that
+// is exact, and how the file is compressed. Files whose decompressed data fits in the sample are counted;
+// for larger ones the line density of the sample is scaled up by the share
+// of the stored file it took, which is rough for compressed files.
+func EstimateLines(path string) (lines int64, exact bool, kind Compression, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false, kind, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return 0, false, kind, err
+	}
+	cr := &countingReader{r: f}
+	r, kind, err := decompress(f, cr)
+	if err != nil {
+		return 0, false, kind, err
+	}
+	defer r.Close()
+	buf := make([]byte, 64*1024)
+	var n int64
+	var last byte
+	for n < estimateSample {
+		k, err := r.Read(buf)
+		if k > 0 {
+			lines += int64(bytes.Count(buf[:k], []byte{'\n'}))
+			last = buf[k-1]
+			n += int64(k)
+		}
+		if err == io.EOF {
+			if n > 0 && last != '\n' {
+				lines++ // a last line without a newline
+			}
+			return lines, true, kind, nil
+		}
+		if err != nil {
+			return 0, false, kind, err
+		}
+	}
+	stored := cr.n - int64(r.br.Buffered())
+	return lines * fi.Size() / stored, false, kind, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Files returns the regular files in dir matching glob. With rotated set
+// it also includes rotated copies such as machine.1.log.1 or
+// machine.1.log.2.gz, i.e. files matching glob+".*". Files are sorted by
+// the name of the log they belong to, and each log's rotated copies come
+// oldest first and before the live file, so reading them in order reads
+// the log in the order it was written. Copies numbered by logrotate go
+// from the highest number down; others, such as machine.1.log.2024-01-31,
+// come first in order of modification time.
+func Files(dir, glob string, rotated bool) ([]string, error) {
+	patterns := []string{filepath.Join(dir, glob)}
+	if rotated {
+		patterns = append(patterns, filepath.Join(dir, glob+".*"))
+	}
+	seen := map[string]bool{}
+	var out []logFile
+	for _, p := range patterns {
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+			fi, err := os.Stat(m)
+			if err != nil || !fi.Mode().IsRegular() {
+				continue
+			}
+			out = append(out, newLogFile(m, filepath.Join(dir, glob), fi))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].less(out[j]) })
+	paths := make([]string, len(out))
+	for i, f := range out {
+		paths[i] = f.path
+	}
+	return paths, nil
+}
+
+// logFile is a file found by Files and where it falls in its log's
+// history.
+type logFile struct {
+	path  string
+	log   string // the live file's path
+	live  bool
+	index int // logrotate's number for the copy, or -1
+	mtime time.Time
+}
+
+// newLogFile places path, which matches pattern or pattern+".*".
+func newLogFile(path, pattern string, fi os.FileInfo) logFile {
+	f := logFile{path: path, log: path, live: true, index: -1, mtime: fi.ModTime()}
+	if ok, _ := filepath.Match(pattern, path); ok {
+		return f
+	}
+	// The log is the shortest prefix ending before a dot that matches.
+	for i := len(filepath.Dir(path)) + 1; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, path[:i]); ok {
+			f.log, f.live = path[:i], false
+			suffix, _, _ := strings.Cut(path[i+1:], ".")
+			if n, err := strconv.Atoi(suffix); err == nil && n >= 0 {
+				f.index = n
+			}
+			break
+		}
+	}
+	return f
+}
+
+func (f logFile) less(g logFile) bool {
+	switch {
+	case f.log != g.log:
+		return f.log < g.log
+	case f.live != g.live:
+		return g.live
+	case (f.index < 0) != (g.index < 0):
+		return f.index < 0
+	case f.index != g.index:
+		return f.index > g.index
+	case !f.mtime.Equal(g.mtime):
+		return f.mtime.Before(g.mtime)
+	}
+	return f.path < g.path
+}
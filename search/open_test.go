@@ -0,0 +1,110 @@
+package search
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeLog writes lines to dir/name, gzipped if the name ends in .gz, and
+// sets its modification time.
+func writeLog(t *testing.T, dir, name, lines string, mtime time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Ext(name) == ".gz" {
+		zw := gzip.NewWriter(f)
+		zw.Write([]byte(lines))
+		err = zw.Close()
+	} else {
+		_, err = f.WriteString(lines)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFilesOrdersRotatedCopiesOldestFirst(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for _, f := range []struct {
+		name string
+		age  time.Duration
+	}{
+		{"vm1.log", 0},
+		{"vm1.log.1", time.Hour},
+		{"vm1.log.2.gz", 2 * time.Hour},
+		{"vm1.log.10.gz", 10 * time.Hour},
+		{"vm1.log.2024-01-01", 30 * time.Hour},
+		{"vm1.log.2024-01-02", 20 * time.Hour},
+		{"vm2.log", 0},
+		{"vm2.log.1", time.Hour},
+		{"notes.txt", 0},
+	} {
+		writeLog(t, dir, f.name, "x\n", now.Add(-f.age))
+	}
+	if err := os.Mkdir(filepath.Join(dir, "vm3.log"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		rotated bool
+		want    []string
+	}{
+		{false, []string{"vm1.log", "vm2.log"}},
+		{true, []string{
+			"vm1.log.2024-01-01", "vm1.log.2024-01-02", "vm1.log.10.gz", "vm1.log.2.gz", "vm1.log.1", "vm1.log",
+			"vm2.log.1", "vm2.log",
+		}},
+	} {
+		got, err := Files(dir, "*.log", tc.rotated)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range got {
+			got[i] = filepath.Base(got[i])
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("Files(rotated=%v) = %v, want %v", tc.rotated, got, tc.want)
+		}
+	}
+}
+
+func TestOpenDecompresses(t *testing.T) {
+	dir := t.TempDir()
+	writeLog(t, dir, "a.log.1.gz", "one\ntwo\n", time.Now())
+	writeLog(t, dir, "a.log", "three\n", time.Now())
+	for name, want := range map[string]struct {
+		text string
+		kind Compression
+	}{
+		"a.log.1.gz": {"one\ntwo\n", Gzip},
+		"a.log":      {"three\n", Plain},
+	} {
+		r, kind, err := Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want.text || kind != want.kind {
+			t.Errorf("Open(%s) = %q, %s; want %q, %s", name, b, kind, want.text, want.kind)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Package search implements the line matcher that workers run over their
+// local log files. It understands the subset of grep options the cluster
+// uses, so results no longer depend on the grep binary installed on a VM.
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Syntax selects how patterns are interpreted.
+type Syntax int
+
+const (
+	Basic    Syntax = iota // POSIX basic regexp (grep -G, the default)
+	Fixed                  // literal strings (grep -F)
+	Extended               // POSIX extended regexp (grep -E)
+	Perl                   // Perl-style regexp as supported by package regexp (grep -P)
+)
+
+// Options is the parsed form of a search request.
+type Options struct {
+	Patterns      []string
+	Syntax        Syntax
+	IgnoreCase    bool  // -i
+	Invert        bool  // -v
+	WholeWord     bool  // -w
+	LineRegexp    bool  // -x
+	Count         bool  // -c
+	LineNumbers   bool  // -n
+	OnlyMatching  bool  // -o
+	MaxCount      int64 // -m, 0 for no limit
+	BeforeContext int   // -B
+	AfterContext  int   // -A
+	Window        TimeRange
+}
+
+var longFlags = map[string]byte{
+	"fixed-strings":   'F',
+	"extended-regexp": 'E',
+	"basic-regexp":    'G',
+	"perl-regexp":     'P',
+	"ignore-case":     'i',
+	"invert-match":    'v',
+	"word-regexp":     'w',
+	"line-regexp":     'x',
+	"count":           'c',
+	"line-number":     'n',
+	"only-matching":   'o',
+	"regexp":          'e',
+	"max-count":       'm',
+	"after-context":   'A',
+	"before-context":  'B',
+	"context":         'C',
+}
+
+// takesArg lists the options that consume a value.
+const takesArg = "emABC"
+
+// ParseArgs parses grep style arguments such as ["-i", "-e", "error"].
+// It is a strict allowlist: only the options listed in Options are
+// accepted, and file operands or options like -f, -r or --include are
+// rejected so a client cannot make a worker read arbitrary files.
+func ParseArgs(args []string) (Options, error) {
+	var o Options
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--":
+			positional = append(positional, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(a, "--"):
+			name, val, hasVal := strings.Cut(a[2:], "=")
+			c, ok := longFlags[name]
+			if !ok {
+				return o, fmt.Errorf("unsupported option %q", a)
+			}
+			if strings.IndexByte(takesArg, c) < 0 {
+				if hasVal {
+					return o, fmt.Errorf("option --%s takes no argument", name)
+				}
+				o.set(c)
+				continue
+			}
+			if !hasVal {
+				if i+1 >= len(args) {
+					return o, fmt.Errorf("option --%s requires an argument", name)
+				}
+				i++
+				val = args[i]
+			}
+			if err := o.setArg(c, val); err != nil {
+				return o, err
+			}
+		case len(a) > 1 && a[0] == '-':
+			for j := 1; j < len(a); j++ {
+				c := a[j]
+				if strings.IndexByte(takesArg, c) < 0 {
+					if !o.set(c) {
+						return o, fmt.Errorf("unsupported option -%c", c)
+					}
+					continue
+				}
+				val := a[j+1:]
+				if val == "" {
+					if i+1 >= len(args) {
+						return o, fmt.Errorf("option -%c requires an argument", c)
+					}
+					i++
+					val = args[i]
+				}
+				if err := o.setArg(c, val); err != nil {
+					return o, err
+				}
+				break
+			}
+		default:
+			positional = append(positional, a)
+		}
+	}
+	if len(o.Patterns) == 0 && len(positional) > 0 {
+		o.Patterns = append(o.Patterns, positional[0])
+		positional = positional[1:]
+	}
+	if len(positional) > 0 {
+		return o, fmt.Errorf("unexpected argument %q", positional[0])
+	}
+	// grep treats a newline in a pattern as separating several patterns.
+	var pats []string
+	for _, p := range o.Patterns {
+		pats = append(pats, strings.Split(p, "\n")...)
+	}
+	o.Patterns = pats
+	return o, o.Validate()
+}
+
+// Validate checks the options for values no search can run with.
+func (o Options) Validate() error {
+	if len(o.Patterns) == 0 {
+		return fmt.Errorf("no pattern given")
+	}
+	if o.MaxCount < 0 || o.BeforeContext < 0 || o.AfterContext < 0 {
+		return fmt.Errorf("max count and context lines must not be negative")
+	}
+	if o.Window.Active() && o.Window.Layout == nil {
+		return fmt.Errorf("time range given but no time layout configured")
+	}
+	if !o.Window.Since.IsZero() && !o.Window.Until.IsZero() && o.Window.Until.Before(o.Window.Since) {
+		return fmt.Errorf("time range ends before it starts")
+	}
+	return nil
+}
+
+func (o *Options) set(c byte) bool {
+	switch c {
+	case 'F':
+		o.Syntax = Fixed
+	case 'E':
+		o.Syntax = Extended
+	case 'G':
+		o.Syntax = Basic
+	case 'P':
+		o.Syntax = Perl
+	case 'i':
+		o.IgnoreCase = true
+	case 'v':
+		o.Invert = true
+	case 'w':
+		o.WholeWord = true
+	case 'x':
+		o.LineRegexp = true
+	case 'c':
+		o.Count = true
+	case 'n':
+		o.LineNumbers = true
+	case 'o':
+		o.OnlyMatching = true
+	default:
+		return false
+	}
+	return true
+}
+
+func (o *Options) setArg(c byte, val string) error {
+	if c == 'e' {
+		o.Patterns = append(o.Patterns, val)
+		return nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		return fmt.Errorf("option -%c: invalid number %q", c, val)
+	}
+	switch c {
+	case 'm':
+		o.MaxCount = int64(n)
+	case 'A':
+		o.AfterContext = n
+	case 'B':
+		o.BeforeContext = n
+	case 'C':
+		o.BeforeContext, o.AfterContext = n, n
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArgsRejects(t *testing.T) {
+	for _, args := range [][]string{
+		{"-f", "/etc/shadow"},
+		{"--file=/etc/shadow"},
+		{"-r", "root", "/"},
+		{"-R", "root"},
+		{"--recursive", "root"},
+		{"--include=*.log", "x"},
+		{"--exclude", "*.gz", "x"},
+		{"-d", "recurse", "x"},
+		{"--devices=read", "x"},
+		{"-z", "x"},
+		{"-ief", "/etc/shadow"},
+		{"error", "/etc/passwd"},
+		{"-e", "error", "/etc/passwd"},
+		{"--", "error", "/etc/passwd"},
+		{"--count=3", "x"},
+		{"--regexp"},
+		{"-e"},
+		{"-m", "-1", "x"},
+		{"-A", "many", "x"},
+		{"--context=-2", "x"},
+		{},
+		{"-i"},
+	} {
+		if o, err := ParseArgs(args); err == nil {
+			t.Errorf("ParseArgs(%q) = %+v, want an error", args, o)
+		}
+	}
+}
+
+func TestParseArgsAccepts(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want Options
+	}{
+		{[]string{"error"}, Options{Patterns: []string{"error"}}},
+		{[]string{"-e", "a", "-e", "b"}, Options{Patterns: []string{"a", "b"}}},
+		{[]string{"-eerror"}, Options{Patterns: []string{"error"}}},
+		{[]string{"--regexp=-v"}, Options{Patterns: []string{"-v"}}},
+		{[]string{"--", "-v"}, Options{Patterns: []string{"-v"}}},
+		{[]string{"a\nb"}, Options{Patterns: []string{"a", "b"}}},
+		{[]string{"-F", "x"}, Options{Patterns: []string{"x"}, Syntax: Fixed}},
+		{[]string{"-E", "x"}, Options{Patterns: []string{"x"}, Syntax: Extended}},
+		{[]string{"-P", "-G", "x"}, Options{Patterns: []string{"x"}, Syntax: Basic}},
+		{[]string{"--perl-regexp", "x"}, Options{Patterns: []string{"x"}, Syntax: Perl}},
+		{[]string{"-ivwxcno", "x"}, Options{Patterns: []string{"x"}, IgnoreCase: true, Invert: true, WholeWord: true,
+			LineRegexp: true, Count: true, LineNumbers: true, OnlyMatching: true}},
+		{[]string{"--ignore-case", "--invert-match", "--word-regexp", "--line-regexp", "--count", "--line-number", "--only-matching", "x"},
+			Options{Patterns: []string{"x"}, IgnoreCase: true, Invert: true, WholeWord: true, LineRegexp: true, Count: true,
+				LineNumbers: true, OnlyMatching: true}},
+		{[]string{"-m", "5", "-A1", "-B", "2", "x"}, Options{Patterns: []string{"x"}, MaxCount: 5, AfterContext: 1, BeforeContext: 2}},
+		{[]string{"-C3", "x"}, Options{Patterns: []string{"x"}, AfterContext: 3, BeforeContext: 3}},
+		{[]string{"--max-count=5", "--context", "1", "-A", "4", "x"}, Options{Patterns: []string{"x"}, MaxCount: 5, AfterContext: 4, BeforeContext: 1}},
+		{[]string{"-im2", "x"}, Options{Patterns: []string{"x"}, IgnoreCase: true, MaxCount: 2}},
+	} {
+		got, err := ParseArgs(tc.args)
+		if err != nil {
+			t.Errorf("ParseArgs(%q): %v", tc.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseArgs(%q) = %+v, want %+v", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestParseArgsNamesRejectedOption(t *testing.T) {
+	_, err := ParseArgs([]string{"-i", "--include=*.log", "x"})
+	if err == nil || !strings.Contains(err.Error(), "--include") {
+		t.Errorf("err = %v, want it to name --include", err)
+	}
+}
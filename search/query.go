@@ -0,0 +1,72 @@
+package search
+
+import (
+	grep "MP1/protoBuilds"
+	"fmt"
+)
+
+// FromQuery converts a typed request into Options.
+func FromQuery(q *grep.Query) (Options, error) {
+	o := Options{
+		Patterns:      q.GetPatterns(),
+		IgnoreCase:    q.GetIgnoreCase(),
+		Invert:        q.GetInvert(),
+		WholeWord:     q.GetWholeWord(),
+		LineRegexp:    q.GetLineRegexp(),
+		LineNumbers:   q.GetLineNumbers(),
+		OnlyMatching:  q.GetOnlyMatching(),
+		MaxCount:      q.GetMaxCount(),
+		BeforeContext: int(q.GetBeforeContext()),
+		AfterContext:  int(q.GetAfterContext()),
+	}
+	switch q.GetSyntax() {
+	case grep.PatternSyntax_BASIC:
+		o.Syntax = Basic
+	case grep.PatternSyntax_FIXED:
+		o.Syntax = Fixed
+	case grep.PatternSyntax_EXTENDED:
+		o.Syntax = Extended
+	case grep.PatternSyntax_PCRE:
+		o.Syntax = Perl
+	}
+	return o, o.Validate()
+}
+
+// FromAggregation converts a typed aggregation into a GroupBy.
+func FromAggregation(a *grep.Aggregation) (GroupBy, error) {
+	if a == nil {
+		return GroupBy{}, fmt.Errorf("aggregate mode needs an aggregation")
+	}
+	return GroupBy{
+		Field:     a.By == grep.GroupBy_FIELD,
+		Index:     int(a.Index),
+		Name:      a.Name,
+		Separator: a.Separator,
+	}, nil
+}
+
+// Query converts Options into the typed request sent to workers. Count is
+// not part of a Query; callers express it through the request mode.
+func (o Options) Query() *grep.Query {
+	q := &grep.Query{
+		Patterns:      o.Patterns,
+		IgnoreCase:    o.IgnoreCase,
+		Invert:        o.Invert,
+		WholeWord:     o.WholeWord,
+		LineRegexp:    o.LineRegexp,
+		LineNumbers:   o.LineNumbers,
+		OnlyMatching:  o.OnlyMatching,
+		MaxCount:      o.MaxCount,
+		BeforeContext: int32(o.BeforeContext),
+		AfterContext:  int32(o.AfterContext),
+	}
+	switch o.Syntax {
+	case Fixed:
+		q.Syntax = grep.PatternSyntax_FIXED
+	case Extended:
+		q.Syntax = grep.PatternSyntax_EXTENDED
+	case Perl:
+		q.Syntax = grep.PatternSyntax_PCRE
+	}
+	return q
+}
@@ -0,0 +1,150 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"time"
+)
+
+// Hit is one line of output from Scan.
+type Hit struct {
+	Line    int64  // 1-based line number in the file
+	Offset  int64  // byte offset of the start of the line in the file, after decompression
+	Text    string // the whole line, or only the matched part with -o
+	Context bool   // a -A/-B context line rather than a selected one
+}
+
+// Searcher runs a compiled Matcher over readers.
+type Searcher struct {
+	opts Options
+	m    *Matcher
+}
+
+// New compiles opts into a Searcher.
+func New(opts Options) (*Searcher, error) {
+	m, err := Compile(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Searcher{opts: opts, m: m}, nil
+}
+
+// Options returns the options the Searcher was built from.
+func (s *Searcher) Options() Options { return s.opts }
+
+// Scan reads r line by line and calls fn for every selected line (or every
+// match with -o) and for the requested context lines around them. fn may be
+// nil when only the count is needed. It returns the number of selected
+// lines, which is what grep -c reports.
+//
+// With an active time window, lines outside it are skipped entirely. Lines
+// without a timestamp of their own, such as stack trace continuations, take
+// the timestamp of the line before them.
+func (s *Searcher) Scan(ctx context.Context, r io.Reader, fn func(Hit) error) (int64, error) {
+	return s.ScanFrom(ctx, r, 0, 0, fn)
+}
+
+// ScanFrom is Scan for a reader that starts partway into a file, after
+// the given number of lines and bytes, so that hits carry the file's line
+// numbers and offsets.
+func (s *Searcher) ScanFrom(ctx context.Context, r io.Reader, lines, offset int64, fn func(Hit) error) (int64, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	before, after := s.opts.BeforeContext, s.opts.AfterContext
+	if s.opts.OnlyMatching || fn == nil {
+		before, after = 0, 0
+	}
+	var pending []Hit // leading context, at most `before` lines
+	n, count := lines, int64(0)
+	next := offset // offset of the line about to be read
+	trailing := 0  // trailing context lines still to print
+	done := false
+	window := s.opts.Window
+	var stamp time.Time
+	stamped := false
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			n++
+			off := next
+			next += int64(len(line))
+			if n%4096 == 0 {
+				if cerr := ctx.Err(); cerr != nil {
+					return count, cerr
+				}
+			}
+			line = bytes.TrimSuffix(line, []byte{'\n'})
+			if window.Active() {
+				if t, ok := window.Layout.Parse(line); ok {
+					stamp, stamped = t, true
+				}
+			}
+			switch {
+			case window.Active() && (!stamped || !window.Contains(stamp)):
+				pending, trailing = pending[:0], 0
+			case done:
+				// -m was reached; only trailing context remains.
+				if trailing == 0 {
+					return count, nil
+				}
+				trailing--
+				if ferr := fn(Hit{Line: n, Offset: off, Text: string(line), Context: true}); ferr != nil {
+					return count, ferr
+				}
+			case s.m.Match(line):
+				count++
+				if fn != nil {
+					for _, h := range pending {
+						if ferr := fn(h); ferr != nil {
+							return count, ferr
+						}
+					}
+					pending = pending[:0]
+					if ferr := s.emit(line, n, off, fn); ferr != nil {
+						return count, ferr
+					}
+					trailing = after
+				}
+				if s.opts.MaxCount > 0 && count >= s.opts.MaxCount {
+					if trailing == 0 {
+						return count, nil
+					}
+					done = true
+				}
+			case trailing > 0:
+				trailing--
+				if ferr := fn(Hit{Line: n, Offset: off, Text: string(line), Context: true}); ferr != nil {
+					return count, ferr
+				}
+			case before > 0:
+				if len(pending) == before {
+					pending = append(pending[:0], pending[1:]...)
+				}
+				pending = append(pending, Hit{Line: n, Offset: off, Text: string(line), Context: true})
+			}
+		}
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+}
+
+func (s *Searcher) emit(line []byte, n, off int64, fn func(Hit) error) error {
+	if !s.opts.OnlyMatching {
+		return fn(Hit{Line: n, Offset: off, Text: string(line)})
+	}
+	for _, loc := range s.m.FindAll(line) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		// With -o the offset is that of the match, as grep -o -b reports.
+		if err := fn(Hit{Line: n, Offset: off + int64(loc[0]), Text: string(line[loc[0]:loc[1]])}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// TimeLayout extracts and parses the timestamp of a log line.
+type TimeLayout struct {
+	name  string
+	parse func(line []byte) (time.Time, bool)
+}
+
+const apacheLayout = "02/Jan/2006:15:04:05 -0700"
+
+// ParseTimeLayout returns the layout named by s: "syslog" (Jan _2 15:04:05
+// at the start of the line), "rfc3339" (the first field, optionally in
+// brackets), "apache" (common log format, the text between [ and ]), or
+// otherwise a Go reference-time layout matched at the start of the line.
+func ParseTimeLayout(s string) (*TimeLayout, error) {
+	switch strings.ToLower(s) {
+	case "syslog":
+		return &TimeLayout{name: "syslog", parse: parseSyslog}, nil
+	case "rfc3339":
+		return &TimeLayout{name: "rfc3339", parse: parseRFC3339}, nil
+	case "apache", "clf":
+		return &TimeLayout{name: "apache", parse: parseApache}, nil
+	case "":
+		return nil, fmt.Errorf("empty time layout")
+	}
+	// Round-trip the reference time, since a layout is not always a valid
+	// value of itself: "Z07:00" parses "Z" as UTC.
+	ref := time.Date(2006, 1, 2, 15, 4, 5, 0, time.FixedZone("MST", -7*60*60))
+	if t, err := time.Parse(s, ref.Format(s)); err != nil {
+		return nil, fmt.Errorf("time layout %q: %v", s, err)
+	} else if t.Equal(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		return nil, fmt.Errorf("time layout %q has no date or time fields", s)
+	}
+	return &TimeLayout{name: s, parse: parseLayout(s)}, nil
+}
+
+// parseLayout returns a parser for the Go layout s at the start of a line.
+// Stamps are not all as wide as the layout: "Z07:00" may be just "Z", and
+// "January", "Monday", "_2" or ".999" vary too. So if the layout's own
+// width does not parse, the stamp is taken to end at a space or tab, or
+// at the end of the line, no further than the widest the layout can be.
+func parseLayout(s string) func(line []byte) (time.Time, bool) {
+	wide := time.Date(2006, 9, 27, 22, 44, 55, 999999999, time.FixedZone("-07:00", -7*60*60))
+	maxWidth := len(wide.Format(s)) + 8 // zone names vary too
+	try := func(stamp []byte) (time.Time, bool) {
+		t, err := time.ParseInLocation(s, string(stamp), time.Local)
+		return t, err == nil
+	}
+	return func(line []byte) (time.Time, bool) {
+		if len(line) >= len(s) {
+			if t, ok := try(line[:len(s)]); ok {
+				return t, true
+			}
+		}
+		for i := 1; i <= len(line) && i <= maxWidth; i++ {
+			if i != len(s) && (i == len(line) || line[i] == ' ' || line[i] == '\t') {
+				if t, ok := try(line[:i]); ok {
+					return t, true
+				}
+			}
+		}
+		return time.Time{}, false
+	}
+}
+
+func (l *TimeLayout) String() string { return l.name }
+
+// Parse returns the timestamp of line, if it has one.
+func (l *TimeLayout) Parse(line []byte) (time.Time, bool) { return l.parse(line) }
+
+func parseSyslog(line []byte) (time.Time, bool) { return syslogStamp(line, time.Now()) }
+
+// syslogStamp parses a syslog timestamp, which has no year, as the latest
+// such time at most a day after now: December lines read in January land
+// in the old year, and Feb 29 in the last leap year.
+func syslogStamp(line []byte, now time.Time) (time.Time, bool) {
+	if len(line) < len(time.Stamp) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(time.Stamp, string(line[:len(time.Stamp)]), time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	for y := now.Year(); y > now.Year()-8; y-- {
+		d := time.Date(y, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+		if d.Day() == t.Day() && !d.After(now.Add(24*time.Hour)) {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseRFC3339(line []byte) (time.Time, bool) {
+	field := line
+	if i := bytes.IndexAny(field, " \t"); i >= 0 {
+		field = field[:i]
+	}
+	field = bytes.TrimSuffix(bytes.TrimPrefix(field, []byte("[")), []byte("]"))
+	t, err := time.Parse(time.RFC3339Nano, string(field))
+	return t, err == nil
+}
+
+func parseApache(line []byte) (time.Time, bool) {
+	i := bytes.IndexByte(line, '[')
+	if i < 0 || len(line)-i-1 < len(apacheLayout) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(apacheLayout, string(line[i+1:i+1+len(apacheLayout)]))
+	return t, err == nil
+}
+
+// TimeRange restricts a search to lines stamped within [Since, Until].
+// A zero bound is open.
+type TimeRange struct {
+	Since, Until time.Time
+	Layout       *TimeLayout
+}
+
+// Active reports whether the range filters anything.
+func (r TimeRange) Active() bool { return !r.Since.IsZero() || !r.Until.IsZero() }
+
+// Contains reports whether t is inside the range.
+func (r TimeRange) Contains(t time.Time) bool {
+	return (r.Since.IsZero() || !t.Before(r.Since)) && (r.Until.IsZero() || !t.After(r.Until))
+}
+
+// tailSize is how much of the end of a plain file is read to find its last
+// timestamp.
+const tailSize = 64 * 1024
+
+// SkipFile reports whether no line of the file at path can fall inside the
+// range, judging by its mtime and the timestamps of its first and last
+// lines. It errs on the side of searching the file.
+func (r TimeRange) SkipFile(path string) bool {
+	if !r.Active() {
+		return false
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if !r.Since.IsZero() && fi.ModTime().Before(r.Since) {
+		return true
+	}
+	f, kind, err := Open(path)
+	if err != nil {
+		return false
+	}
+	head := make([]byte, tailSize)
+	n, _ := io.ReadFull(f, head)
+	f.Close()
+	if first, ok := r.firstStamp(head[:n]); ok && !r.Until.IsZero() && first.After(r.Until) {
+		return true
+	}
+	// Only plain files can be read from the end cheaply.
+	if kind != Plain || r.Since.IsZero() {
+		return false
+	}
+	pf, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer pf.Close()
+	off := fi.Size() - tailSize
+	if off < 0 {
+		off = 0
+	}
+	tail := make([]byte, fi.Size()-off)
+	if _, err := pf.ReadAt(tail, off); err != nil && err != io.EOF {
+		return false
+	}
+	last, ok := r.lastStamp(tail)
+	return ok && last.Before(r.Since)
+}
+
+func (r TimeRange) firstStamp(buf []byte) (time.Time, bool) {
+	for len(buf) > 0 {
+		line := buf
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			line, buf = buf[:i], buf[i+1:]
+		} else {
+			buf = nil
+		}
+		if t, ok := r.Layout.Parse(line); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r TimeRange) lastStamp(buf []byte) (time.Time, bool) {
+	buf = bytes.TrimSuffix(buf, []byte{'\n'})
+	for len(buf) > 0 {
+		line := buf
+		if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+			line, buf = buf[i+1:], buf[:i]
+		} else {
+			buf = nil
+		}
+		if t, ok := r.Layout.Parse(line); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseTimeArg parses a -since/-until value: an RFC 3339 time,
+// "2006-01-02 15:04:05", "2006-01-02", a clock time "15:04[:05]" meaning
+// today, or a duration such as "30m" meaning that long before now.
+func ParseTimeArg(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			y, m, d := now.Date()
+			return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, now.Location()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse time %q", s)
+}
@@ -0,0 +1,239 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeLayouts(t *testing.T) {
+	utc := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	for _, tc := range []struct {
+		layout, line string
+		want         time.Time // zero for no timestamp
+	}{
+		{"rfc3339", "2024-03-10T12:00:00Z boot", utc("2024-03-10T12:00:00Z")},
+		{"rfc3339", "2024-03-10T12:00:00+05:30 boot", utc("2024-03-10T06:30:00Z")},
+		{"rfc3339", "[2024-03-10T12:00:00.25-08:00]\tboot", utc("2024-03-10T20:00:00.25Z")},
+		{"rfc3339", "2024-03-10 12:00:00 boot", time.Time{}},
+		{"rfc3339", "boot 2024-03-10T12:00:00Z", time.Time{}},
+		{"rfc3339", "", time.Time{}},
+		{"apache", `10.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 2326`, utc("2000-10-10T20:55:36Z")},
+		{"apache", `10.0.0.1 - - [10/Oct/2000:13:55:36 +0000] "GET / HTTP/1.0" 200 2326`, utc("2000-10-10T13:55:36Z")},
+		{"clf", `[10/Oct/2000:13:55:36 +0200] x`, utc("2000-10-10T11:55:36Z")},
+		{"apache", `10.0.0.1 - - "GET / HTTP/1.0" 200 2326`, time.Time{}},
+		{"apache", `[10/Oct/2000:13:55]`, time.Time{}},
+		{"2006-01-02T15:04:05Z07:00", "2024-03-10T12:00:00-01:00 x", utc("2024-03-10T13:00:00Z")},
+		{"2006/01/02 15:04:05", "2024/03/10 12:00:00 x", time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)},
+		{"2006/01/02 15:04:05", "2024/03/10 x", time.Time{}},
+		// Custom layouts whose stamps are narrower or wider than the layout.
+		{"2006-01-02T15:04:05Z07:00", "2024-03-10T12:00:00Z GET /", utc("2024-03-10T12:00:00Z")},
+		{"2006-01-02T15:04:05Z07:00", "2024-03-10T12:00:00Z", utc("2024-03-10T12:00:00Z")},
+		{"2006-01-02T15:04:05.999Z07:00", "2024-03-10T12:00:00.5Z x", utc("2024-03-10T12:00:00.5Z")},
+		{"2006-01-02T15:04:05.999Z07:00", "2024-03-10T12:00:00+01:00 x", utc("2024-03-10T11:00:00Z")},
+		{"Monday, January 2 2006 15:04:05 -0700", "Friday, March 8 2024 09:00:00 +0000 boot", utc("2024-03-08T09:00:00Z")},
+		{"Monday, January 2 2006 15:04:05 -0700", "Wednesday, September 27 2006 22:44:55 -0100 boot", utc("2006-09-27T23:44:55Z")},
+		{"Jan _2 2006 15:04:05 MST", "Mar  8 2024 09:00:00 UTC x", utc("2024-03-08T09:00:00Z")},
+		{"Monday, January 2 2006 15:04:05 -0700", "Friday, March 8 2024 boot", time.Time{}},
+	} {
+		l, err := ParseTimeLayout(tc.layout)
+		if err != nil {
+			t.Fatalf("ParseTimeLayout(%q): %v", tc.layout, err)
+		}
+		got, ok := l.Parse([]byte(tc.line))
+		if ok != !tc.want.IsZero() || !got.Equal(tc.want) {
+			t.Errorf("%s: Parse(%q) = %v, %v; want %v", tc.layout, tc.line, got, ok, tc.want)
+		}
+	}
+}
+
+func TestParseTimeLayoutErrors(t *testing.T) {
+	for _, s := range []string{"", "text"} {
+		if _, err := ParseTimeLayout(s); err == nil {
+			t.Errorf("ParseTimeLayout(%q) succeeded", s)
+		}
+	}
+}
+
+func TestSyslogYear(t *testing.T) {
+	local := func(y int, m time.Month, d, h int) time.Time { return time.Date(y, m, d, h, 0, 0, 0, time.Local) }
+	for _, tc := range []struct {
+		now  time.Time
+		line string
+		want time.Time // zero for no timestamp
+	}{
+		{local(2025, 6, 15, 12), "Jun 15 10:00:00 host sshd: x", local(2025, 6, 15, 10)},
+		{local(2025, 1, 2, 12), "Dec 31 23:00:00 host x", local(2024, 12, 31, 23)},
+		{local(2025, 1, 2, 12), "Jan  2 10:00:00 host x", local(2025, 1, 2, 10)},
+		// A clock a little ahead of the reader's stays in this year.
+		{local(2025, 1, 2, 12), "Jan  3 09:00:00 host x", local(2025, 1, 3, 9)},
+		{local(2025, 1, 2, 12), "Mar  1 00:00:00 host x", local(2024, 3, 1, 0)},
+		{local(2025, 1, 2, 12), "Feb 29 12:00:00 host x", local(2024, 2, 29, 12)},
+		{local(2024, 3, 1, 12), "Feb 29 12:00:00 host x", local(2024, 2, 29, 12)},
+		{local(2025, 1, 2, 12), "host x", time.Time{}},
+		{local(2025, 1, 2, 12), "Foo 29 12:00:00 host x", time.Time{}},
+	} {
+		got, ok := syslogStamp([]byte(tc.line), tc.now)
+		if ok != !tc.want.IsZero() || !got.Equal(tc.want) {
+			t.Errorf("at %v: syslogStamp(%q) = %v, %v; want %v", tc.now, tc.line, got, ok, tc.want)
+		}
+	}
+}
+
+func TestTimeRangeContains(t *testing.T) {
+	at := func(h int) time.Time { return time.Date(2024, 1, 1, h, 0, 0, 0, time.UTC) }
+	east := time.FixedZone("UTC+2", 2*60*60)
+	for _, tc := range []struct {
+		r    TimeRange
+		t    time.Time
+		want bool
+	}{
+		{TimeRange{}, at(5), true},
+		{TimeRange{Since: at(10)}, at(9), false},
+		{TimeRange{Since: at(10)}, at(10), true},
+		{TimeRange{Until: at(10)}, at(10), true},
+		{TimeRange{Until: at(10)}, at(11), false},
+		{TimeRange{Since: at(10), Until: at(12)}, at(11), true},
+		// Bounds and stamps in different zones compare as instants.
+		{TimeRange{Since: at(10), Until: at(12)}, time.Date(2024, 1, 1, 13, 0, 0, 0, east), true},
+		{TimeRange{Since: at(10), Until: at(12)}, time.Date(2024, 1, 1, 11, 0, 0, 0, east), false},
+	} {
+		if got := tc.r.Contains(tc.t); got != tc.want {
+			t.Errorf("%+v.Contains(%v) = %v, want %v", tc.r, tc.t, got, tc.want)
+		}
+	}
+}
+
+func TestScanWindow(t *testing.T) {
+	layout, err := ParseTimeLayout("rfc3339")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := strings.Join([]string{
+		"x before any timestamp",
+		"2024-01-01T10:00:00Z x a",
+		"  x continues a",
+		"2024-01-01T12:00:00+01:00 x b",
+		"  x continues b",
+		"2024-01-01T11:30:00Z x c",
+		"2024-01-01T12:00:00Z x d",
+		"",
+	}, "\n")
+	for _, tc := range []struct {
+		since, until string
+		want         []string
+	}{
+		{"2024-01-01T10:30:00Z", "2024-01-01T11:30:00Z", []string{
+			"2024-01-01T12:00:00+01:00 x b", "  x continues b", "2024-01-01T11:30:00Z x c"}},
+		{"", "2024-01-01T10:00:00Z", []string{"2024-01-01T10:00:00Z x a", "  x continues a"}},
+		{"2024-01-01T11:45:00+00:00", "", []string{"2024-01-01T12:00:00Z x d"}},
+		{"2025-01-01T00:00:00Z", "", nil},
+	} {
+		w := TimeRange{Layout: layout}
+		for _, b := range []struct {
+			s   string
+			dst *time.Time
+		}{{tc.since, &w.Since}, {tc.until, &w.Until}} {
+			if b.s != "" {
+				*b.dst, _ = time.Parse(time.RFC3339, b.s)
+			}
+		}
+		s, err := New(Options{Patterns: []string{"x"}, Window: w})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		n, err := s.Scan(context.Background(), strings.NewReader(log), func(h Hit) error {
+			got = append(got, h.Text)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, tc.want) || n != int64(len(tc.want)) {
+			t.Errorf("window %s..%s: got %d %q, want %q", tc.since, tc.until, n, got, tc.want)
+		}
+	}
+}
+
+func TestSkipFile(t *testing.T) {
+	dir := t.TempDir()
+	layout, err := ParseTimeLayout("rfc3339")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(h int) time.Time { return time.Date(2024, 1, 1, h, 0, 0, 0, time.UTC) }
+	write := func(name, text string, mtime time.Time) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	stamped := write("stamped.log", "no stamp yet\n2024-01-01T10:00:00Z a\n2024-01-01T11:00:00Z b\n  trailing\n", at(11))
+	plain := write("plain.log", "no\nstamps\n", at(11))
+	for _, tc := range []struct {
+		path         string
+		since, until time.Time
+		want         bool
+	}{
+		{stamped, time.Time{}, time.Time{}, false},
+		{stamped, at(10), at(11), false},
+		{stamped, time.Time{}, at(9), true},  // first stamp after the range
+		{stamped, at(12), time.Time{}, true}, // modified before the range
+		{plain, at(9), at(10), false},
+		{filepath.Join(dir, "missing.log"), at(9), at(10), false},
+	} {
+		r := TimeRange{Since: tc.since, Until: tc.until, Layout: layout}
+		if got := r.SkipFile(tc.path); got != tc.want {
+			t.Errorf("%s: SkipFile(%s) = %v, want %v", filepath.Base(tc.path), tc.since.Format(time.Kitchen)+".."+tc.until.Format(time.Kitchen), got, tc.want)
+		}
+	}
+	// Touched after the range starts, but its last stamp is before it.
+	if err := os.Chtimes(stamped, at(13), at(13)); err != nil {
+		t.Fatal(err)
+	}
+	if !(TimeRange{Since: at(12), Layout: layout}).SkipFile(stamped) {
+		t.Errorf("SkipFile kept a file whose last stamp is before -since")
+	}
+}
+
+func TestParseTimeArg(t *testing.T) {
+	loc := time.FixedZone("test", -5*60*60)
+	now := time.Date(2024, 6, 15, 12, 30, 0, 0, loc)
+	for _, tc := range []struct {
+		arg  string
+		want time.Time
+	}{
+		{"90m", time.Date(2024, 6, 15, 11, 0, 0, 0, loc)},
+		{"2024-06-01T00:00:00Z", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-06-01 08:00:00", time.Date(2024, 6, 1, 8, 0, 0, 0, loc)},
+		{"2024-06-01T08:00:00", time.Date(2024, 6, 1, 8, 0, 0, 0, loc)},
+		{"2024-06-01", time.Date(2024, 6, 1, 0, 0, 0, 0, loc)},
+		{"09:15", time.Date(2024, 6, 15, 9, 15, 0, 0, loc)},
+		{"09:15:30", time.Date(2024, 6, 15, 9, 15, 30, 0, loc)},
+	} {
+		got, err := ParseTimeArg(tc.arg, now)
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("ParseTimeArg(%q) = %v, %v; want %v", tc.arg, got, err, tc.want)
+		}
+	}
+	for _, arg := range []string{"", "yesterday", "2024-13-01"} {
+		if _, err := ParseTimeArg(arg, now); err == nil {
+			t.Errorf("ParseTimeArg(%q) succeeded", arg)
+		}
+	}
+}
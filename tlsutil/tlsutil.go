@@ -0,0 +1,114 @@
+// Package tlsutil sets up TLS between coordinators and workers, and
+// between workers, from PEM files named in cluster.properties or flags.
+// With a CA configured, workers demand a certificate signed by it from
+// every client (mutual TLS), so only holders of cluster certificates can
+// search the logs, unless an ACL lets callers identify themselves with a
+// token instead.
+package tlsutil
+
+import (
+	"MP1/properties"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Files are the PEM files one node uses. Workers present Cert as both
+// server and client certificate; coordinators present it as a client
+// certificate. CA verifies the other end in both directions.
+type Files struct {
+	Cert string // certificate chain
+	Key  string // private key for Cert
+	CA   string // CA certificates to trust; empty for the system roots and no client authentication
+}
+
+// FromProps reads tls.cert, tls.key and tls.ca.
+func FromProps(p properties.Props) Files {
+	return Files{Cert: p["tls.cert"], Key: p["tls.key"], CA: p["tls.ca"]}
+}
+
+// Override returns f with the files set in o replacing its own, so flags
+// can override cluster.properties.
+func (f Files) Override(o Files) Files {
+	if o.Cert != "" {
+		f.Cert = o.Cert
+	}
+	if o.Key != "" {
+		f.Key = o.Key
+	}
+	if o.CA != "" {
+		f.CA = o.CA
+	}
+	return f
+}
+
+// Enabled reports whether any TLS file is configured. Without one,
+// connections are plaintext.
+func (f Files) Enabled() bool {
+	return f.Cert != "" || f.Key != "" || f.CA != ""
+}
+
+// ServerOption returns the option that makes a gRPC server speak TLS,
+// verifying client certificates against CA when CA is set. They are
+// required unless optionalClientCert, for servers that can also identify
+// callers another way, such as by a bearer token.
+func (f Files) ServerOption(optionalClientCert bool) (grpc.ServerOption, error) {
+	if f.Cert == "" || f.Key == "" {
+		return nil, fmt.Errorf("tls: a worker needs both a certificate and a key")
+	}
+	cert, err := tls.LoadX509KeyPair(f.Cert, f.Key)
+	if err != nil {
+		return nil, fmt.Errorf("tls: %v", err)
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if f.CA != "" {
+		if cfg.ClientCAs, err = loadPool(f.CA); err != nil {
+			return nil, err
+		}
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		if optionalClientCert {
+			cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	return grpc.Creds(credentials.NewTLS(cfg)), nil
+}
+
+// DialOption returns the option that makes a gRPC client speak TLS,
+// verifying servers against CA and presenting Cert if set.
+func (f Files) DialOption() (grpc.DialOption, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if (f.Cert == "") != (f.Key == "") {
+		return nil, fmt.Errorf("tls: a certificate needs a key and a key a certificate")
+	}
+	if f.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(f.Cert, f.Key)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if f.CA != "" {
+		pool, err := loadPool(f.CA)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(cfg)), nil
+}
+
+func loadPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("tls: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("tls: no certificates in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,123 @@
+package tlsutil
+
+import (
+	grep "MP1/protoBuilds"
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testCA writes a new CA to dir and issues name.crt and name.key from it
+// for each name, valid for localhost.
+func testCA(t *testing.T, dir string, names ...string) Files {
+	t.Helper()
+	ca, err := NewCA(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Files{CA: filepath.Join(dir, "ca.crt")}
+	if err := ca.WriteFiles(f.CA, filepath.Join(dir, "ca.key")); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range names {
+		if err := ca.Issue(n, []string{"localhost", "127.0.0.1"}, time.Hour, filepath.Join(dir, n+".crt"), filepath.Join(dir, n+".key")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+func pair(f Files, dir, name string) Files {
+	f.Cert, f.Key = filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	return f
+}
+
+// call serves a GrepService implementing nothing with server's files and
+// calls it with client's. A completed handshake gets Unimplemented.
+func call(t *testing.T, server Files, optionalClientCert bool, client Files) error {
+	t.Helper()
+	sopt, err := server.ServerOption(optionalClientCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer(sopt)
+	grep.RegisterGrepServiceServer(srv, grep.UnimplementedGrepServiceServer{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	dopt, err := client.DialOption()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.NewClient("passthrough:///localhost", dopt, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = grep.NewGrepServiceClient(conn).Members(ctx, &grep.MembersRequest{})
+	return err
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := testCA(t, dir, "vm1", "coordinator")
+	worker, coord := pair(ca, dir, "vm1"), pair(ca, dir, "coordinator")
+
+	if err := call(t, worker, false, coord); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("call with a cluster certificate: %v, want a completed handshake", err)
+	}
+
+	// A client without a certificate gets in only where another way to
+	// identify callers is allowed.
+	if err := call(t, worker, false, ca); status.Code(err) == codes.Unimplemented {
+		t.Fatal("call without a client certificate was accepted")
+	}
+	if err := call(t, worker, true, ca); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("call without a client certificate, which is optional: %v, want a completed handshake", err)
+	}
+}
+
+func TestRejectsCertificateFromAnotherCA(t *testing.T) {
+	dir, other := t.TempDir(), t.TempDir()
+	ca := testCA(t, dir, "vm1")
+	rogue := testCA(t, other, "coordinator")
+	// The client trusts the cluster CA but holds a certificate from another.
+	client := pair(ca, other, "coordinator")
+	for _, optional := range []bool{false, true} {
+		if err := call(t, pair(ca, dir, "vm1"), optional, client); status.Code(err) == codes.Unimplemented {
+			t.Fatalf("optional=%v: call with a certificate from another CA was accepted", optional)
+		}
+	}
+
+	// Nor does a client trusting only the other CA accept the server.
+	if err := call(t, pair(ca, dir, "vm1"), true, rogue); status.Code(err) == codes.Unimplemented {
+		t.Fatal("client accepted a server certificate from a CA it does not trust")
+	}
+}
+
+func TestLoadCAReadsWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	testCA(t, dir)
+	ca, err := LoadCA(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ca.Cert.IsCA || ca.Cert.Subject.CommonName == "" {
+		t.Fatalf("loaded CA certificate %v is not a CA", ca.Cert.Subject)
+	}
+	if _, err := LoadCA(filepath.Join(dir, "ca.key"), filepath.Join(dir, "ca.crt")); err == nil {
+		t.Fatal("LoadCA accepted a key as the certificate")
+	}
+}
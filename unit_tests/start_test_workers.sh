@@ -22,7 +22,7 @@ deploy_host() {
             echo "Starting worker..."
             export GOTOOLCHAIN=auto
             go mod tidy
-            nohup go run worker/main.go -addr ":$port" -logdir /root/generated_logs -glob "$glob" -label "$label" > "$out" 2>&1 &
+            nohup go run ./worker -addr ":$port" -logdir /root/generated_logs -glob "$glob" -label "$label" > "$out" 2>&1 &
 EOF
         echo "Disconnected from $host"
         echo "------------------------"
@@ -1,11 +1,15 @@
+//go:build ignore
+
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -122,17 +126,19 @@ func RunTestCase(testCase TestCase) (*TestResult, error) {
 
 	// Build coordinator command
 	args := []string{
-		"run", "../coordinator/main.go",
+		"run", "../coordinator",
 		"-props", "../cluster.properties",
 		"-mode", testCase.Mode,
+		"-format", "ndjson",
 		"--",
 	}
 	args = append(args, testCase.GrepArgs...)
 	fmt.Printf("args: %v\n", args)
 
-	// Execute coordinator
+	// Execute coordinator; diagnostics on stderr are passed through
 	cmd := exec.Command("go", args...)
-	output, err := cmd.CombinedOutput()
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
 	duration := time.Since(startTime)
 
 	if err != nil {
@@ -151,29 +157,25 @@ func RunTestCase(testCase TestCase) (*TestResult, error) {
 		ActualPerVM: make(map[string]int),
 	}
 
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+	// One JSON record per line; the summary record carries per-VM counts
+	sc := bufio.NewScanner(bytes.NewReader(output))
+	for sc.Scan() {
+		var rec struct {
+			Type    string `json:"type"`
+			Total   int    `json:"total"`
+			Workers []struct {
+				Host  string `json:"host"`
+				Count int    `json:"count"`
+				Error string `json:"error"`
+			} `json:"workers"`
 		}
-
-		// Look for VM count results
-		if strings.Contains(line, "] count=") {
-			parts := strings.Split(line, "] count=")
-			if len(parts) == 2 {
-				vmName := strings.TrimPrefix(parts[0], "[")
-				if count, err := strconv.Atoi(parts[1]); err == nil {
-					result.ActualPerVM[vmName] = count
-					result.ActualCount += count
-				}
-			}
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil || rec.Type != "summary" {
+			continue
 		}
-
-		// Look for total count: TOTAL_COUNT=123
-		if strings.HasPrefix(line, "TOTAL_COUNT=") {
-			if count, err := strconv.Atoi(strings.TrimPrefix(line, "TOTAL_COUNT=")); err == nil {
-				result.ActualCount = count
+		result.ActualCount = rec.Total
+		for _, w := range rec.Workers {
+			if w.Error == "" {
+				result.ActualPerVM[w.Host] = w.Count
 			}
 		}
 	}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	grep "MP1/protoBuilds"
+	"MP1/search"
+	"container/heap"
+	"context"
+)
+
+const (
+	// maxGroups caps the keys one aggregation keeps, bounding worker memory
+	// when grouping by something like a client IP.
+	maxGroups = 100000
+	// groupsPerResponse keeps each response well under gRPC's default
+	// message size limit.
+	groupsPerResponse = 5000
+)
+
+// aggregate counts the lines of files selected by sr by key and sends the
+// partial counts, spread over as many responses as needed. The last
+// response carries the number of lines selected and those not counted
+// under any key kept once the key limit was reached.
+func (s *server) aggregate(ctx context.Context, stream grep.GrepService_SearchServer, sr *search.Searcher, key func([]byte) (string, bool), files []string, shard string, limit int32) error {
+	keep := maxGroups
+	if limit > 0 && int(limit) < keep {
+		keep = int(limit)
+	}
+	counts := newHeavyHitters(keep)
+	var lines int64
+	for _, fp := range files {
+		n, err := s.scanFile(ctx, sr, fp, func(h search.Hit) error {
+			k, ok := key([]byte(h.Text))
+			if !ok {
+				return nil
+			}
+			counts.add(k)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		lines += n
+	}
+	s.logf("sending %d groups for %d lines", len(counts.groups), lines)
+	resp := &grep.SearchResponse{Host: s.workerHost, Shard: shard}
+	var other int64
+	for k, g := range counts.groups {
+		resp.Groups = append(resp.Groups, &grep.GroupCount{Key: k, Count: g.count - g.base})
+		other += g.base
+		if len(resp.Groups) == groupsPerResponse {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+			resp = &grep.SearchResponse{Host: s.workerHost, Shard: shard}
+		}
+	}
+	resp.Count, resp.OtherCount = lines, other
+	return stream.Send(resp)
+}
+
+// heavyHitters counts keys in at most max groups with the space-saving
+// algorithm: once every group is taken, a new key takes over the one with
+// the lowest count. A key making up more than 1/max of the lines is then
+// sure to be kept, even if it first appears after max other keys.
+type heavyHitters struct {
+	max    int
+	groups map[string]*group
+	heap   groupHeap // built once every group is taken
+}
+
+type group struct {
+	key   string
+	count int64 // the group's lines, including those of keys it replaced
+	base  int64 // count when key took the group over
+	index int   // in the heap
+}
+
+func newHeavyHitters(max int) *heavyHitters {
+	return &heavyHitters{max: max, groups: map[string]*group{}}
+}
+
+func (h *heavyHitters) add(key string) {
+	if g, ok := h.groups[key]; ok {
+		g.count++
+		if h.heap != nil {
+			heap.Fix(&h.heap, g.index)
+		}
+		return
+	}
+	if len(h.groups) < h.max {
+		h.groups[key] = &group{key: key, count: 1}
+		return
+	}
+	if h.heap == nil {
+		h.heap = make(groupHeap, 0, len(h.groups))
+		for _, g := range h.groups {
+			g.index = len(h.heap)
+			h.heap = append(h.heap, g)
+		}
+		heap.Init(&h.heap)
+	}
+	g := h.heap[0]
+	delete(h.groups, g.key)
+	g.key, g.base = key, g.count
+	g.count++
+	h.groups[key] = g
+	heap.Fix(&h.heap, 0)
+}
+
+// groupHeap orders groups by count, lowest first.
+type groupHeap []*group
+
+func (q groupHeap) Len() int           { return len(q) }
+func (q groupHeap) Less(i, j int) bool { return q[i].count < q[j].count }
+func (q groupHeap) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+
+func (q *groupHeap) Push(x any) {
+	g := x.(*group)
+	g.index = len(*q)
+	*q = append(*q, g)
+}
+
+func (q *groupHeap) Pop() any {
+	old := *q
+	g := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return g
+}
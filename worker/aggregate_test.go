@@ -0,0 +1,48 @@
+package main
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestHeavyHittersKeepsFrequentKeys(t *testing.T) {
+	h := newHeavyHitters(3)
+	// Rare keys fill every group before the frequent ones show up.
+	for _, k := range strings.Fields("r1 r2 r3 r4 a b a a b a r5 b a a b b a") {
+		h.add(k)
+	}
+	got := map[string]int64{}
+	var other int64
+	for k, g := range h.groups {
+		got[k] = g.count - g.base
+		other += g.base
+	}
+	if got["a"] != 7 || got["b"] != 5 {
+		t.Fatalf("counts = %v, want a: 7 and b: 5", got)
+	}
+	var counted int64
+	for n := range maps.Values(got) {
+		counted += n
+	}
+	if counted+other != 17 {
+		t.Fatalf("counted %d and other %d, want 17 lines in all", counted, other)
+	}
+}
+
+func TestHeavyHittersExactBelowLimit(t *testing.T) {
+	h := newHeavyHitters(10)
+	for _, k := range strings.Fields("a b a c a b") {
+		h.add(k)
+	}
+	got := map[string]int64{}
+	for k, g := range h.groups {
+		if g.base != 0 {
+			t.Fatalf("%s has base %d below the limit", k, g.base)
+		}
+		got[k] = g.count
+	}
+	if want := map[string]int64{"a": 3, "b": 2, "c": 1}; !maps.Equal(got, want) {
+		t.Fatalf("counts = %v, want %v", got, want)
+	}
+}
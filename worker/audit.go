@@ -0,0 +1,119 @@
+package main
+
+import (
+	"MP1/auth"
+	grep "MP1/protoBuilds"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Search runs a search, counts it in the metrics and, with -audit,
+// records who ran it, what it read and sent, and how it ended.
+func (s *server) Search(req *grep.SearchRequest, stream grep.GrepService_SearchServer) error {
+	start := time.Now()
+	ctx := stream.Context()
+	rec := &grep.AuditRecord{Time: timestamppb.New(start), Host: s.workerHost, User: auth.Identity(ctx), Request: req}
+	if p, ok := peer.FromContext(ctx); ok {
+		rec.Peer = p.Addr.String()
+	}
+	err := s.search(req, &auditStream{GrepService_SearchServer: stream, rec: rec}, rec)
+	d, code := time.Since(start), codeOf(err)
+	s.metrics.observe(searchLabel(req.Mode, req.Follow), code.String(), d)
+	s.metrics.linesSent.Add(float64(rec.Lines))
+	if s.audit == nil {
+		return err
+	}
+	rec.DurationMs = d.Milliseconds()
+	rec.Code = code.String()
+	if err != nil {
+		rec.Error = status.Convert(err).Message()
+	}
+	if werr := s.audit.Write(rec); werr != nil {
+		s.logf("audit: %v", werr)
+	}
+	return err
+}
+
+// codeOf is the gRPC status code a handler's error reaches the client as.
+func codeOf(err error) codes.Code {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Code()
+	}
+	return status.Code(err)
+}
+
+// auditStream counts what a search sends into its audit record.
+type auditStream struct {
+	grep.GrepService_SearchServer
+	rec *grep.AuditRecord
+}
+
+func (a *auditStream) Send(r *grep.SearchResponse) error {
+	a.tally(r)
+	return a.GrepService_SearchServer.Send(r)
+}
+
+func (a *auditStream) SendMsg(m any) error {
+	if r, ok := m.(*grep.SearchResponse); ok {
+		a.tally(r)
+	}
+	return a.GrepService_SearchServer.SendMsg(m)
+}
+
+func (a *auditStream) tally(r *grep.SearchResponse) {
+	switch {
+	case r.LineNumber > 0:
+		a.rec.Lines++
+	case r.FilePath != "":
+		// grep -c in lines mode: the file's count in place of a line.
+		n, _ := strconv.ParseInt(r.Log, 10, 64)
+		a.rec.Count += n
+	default:
+		a.rec.Count += r.Count
+	}
+}
+
+// Audit sends the audit records matching req, oldest first.
+func (s *server) Audit(req *grep.AuditRequest, stream grep.GrepService_AuditServer) error {
+	if err := auth.Require(stream.Context(), auth.ModeAudit); err != nil {
+		return err
+	}
+	if s.audit == nil {
+		return status.Errorf(codes.FailedPrecondition, "the audit log is off; start the worker with -audit")
+	}
+	// Collect first: only the newest limit records are sent, and a slow
+	// client should not keep the log's files open.
+	var recs []*grep.AuditRecord
+	limit := int(req.Limit)
+	err := s.audit.Read(func(r *grep.AuditRecord) error {
+		t := r.Time.AsTime()
+		if (req.Since != nil && t.Before(req.Since.AsTime())) || (req.Until != nil && t.After(req.Until.AsTime())) ||
+			(req.User != "" && r.User != req.User) {
+			return nil
+		}
+		recs = append(recs, r)
+		if limit > 0 && len(recs) >= 2*limit {
+			recs = append(recs[:0], recs[len(recs)-limit:]...)
+		}
+		return nil
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "audit: %v", err)
+	}
+	if limit > 0 && len(recs) > limit {
+		recs = recs[len(recs)-limit:]
+	}
+	for _, r := range recs {
+		if err := stream.Send(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
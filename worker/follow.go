@@ -0,0 +1,106 @@
+package main
+
+import (
+	"MP1/auth"
+	grep "MP1/protoBuilds"
+	"MP1/search"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// follow streams lines appended to a shard's files until the client goes
+// away or maxResults selected lines have been sent. The glob is re-evaluated as it runs; files that appear later are
+// followed from their first line, and added to rec. Rotated archives are never followed.
+func (s *server) follow(stream grep.GrepService_SearchServer, sr *search.Searcher, src logSource, maxResults int64, rec *grep.AuditRecord) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	var mu sync.Mutex // stream.Send and limit are not safe for concurrent use
+	limited := limit(maxResults, stream.Send)
+	send := func(r *grep.SearchResponse) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return limited(r)
+	}
+	errc := make(chan error, 1)
+	hunkIDs := new(atomic.Int64)
+
+	rescan := time.NewTicker(10 * s.followPoll)
+	defer rescan.Stop()
+	followed := map[string]bool{}
+	for first := true; ; first = false {
+		files, err := search.Files(src.dir, src.glob, false)
+		if err != nil {
+			return status.Errorf(codes.Internal, "glob: %v", err)
+		}
+		files = auth.FromContext(ctx).Filter(files)
+		for _, fp := range files {
+			if followed[fp] {
+				continue
+			}
+			followed[fp] = true
+			rec.Files = append(rec.Files, fp)
+			s.logf("following %s", fp)
+			wg.Add(1)
+			go func(fp string, fromStart bool) {
+				defer wg.Done()
+				hk := newHunks(sr.Options(), hunkIDs)
+				if err := s.followFile(ctx, sr, src.shard, fp, fromStart, hk, send); err != nil && ctx.Err() == nil {
+					select {
+					case errc <- err:
+					default:
+					}
+				}
+			}(fp, !first)
+		}
+		select {
+		case <-ctx.Done():
+			s.logf("follow ended: %v", ctx.Err())
+			return nil
+		case err := <-errc:
+			if err == errLimit {
+				s.logf("follow stopped after %d results", maxResults)
+				return nil
+			}
+			return err
+		case <-rescan.C:
+		}
+	}
+}
+
+// followFile sends the lines of one file selected by sr as they are
+// written, through rotations and truncations, until ctx is done or -m is
+// reached.
+func (s *server) followFile(ctx context.Context, sr *search.Searcher, shard, fp string, fromStart bool, hk *hunks, send func(*grep.SearchResponse) error) error {
+	fl := search.Follow(fp, s.followPoll, fromStart)
+	defer fl.Close()
+	opts := sr.Options()
+	for gen := 0; ; gen++ {
+		r, lines, offset, err := fl.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if gen > 0 {
+			s.logf("%s was rotated or truncated; following it from the start", fp)
+			hk.id = 0 // line numbers start again
+		}
+		n, err := sr.ScanFrom(ctx, s.metrics.scanned(r), lines, offset, func(h search.Hit) error {
+			return send(s.lineResponse(shard, fp, h, hk.of(fp, h.Line)))
+		})
+		s.metrics.linesMatched.Add(float64(n))
+		if err != nil {
+			return err
+		}
+		if opts.MaxCount > 0 && n >= opts.MaxCount {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"MP1/auth"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// limits keeps a worker from being swamped: it caps the searches running
+// at once, the searches waiting for one of those slots, and how fast each
+// client may start searches. Refused searches fail with ResourceExhausted
+// and a RetryInfo saying when trying again is likely to work.
+type limits struct {
+	slots     chan struct{} // one token per running search; nil for no cap
+	maxQueued int
+	rate      float64 // searches per second per client; 0 for no limit
+	burst     float64
+
+	mu      sync.Mutex
+	queued  int
+	avg     time.Duration // moving average of search durations
+	buckets map[string]*bucket
+}
+
+// bucket is a client's token bucket: a search takes a token, and tokens
+// come back at the limit's rate up to its burst.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// maxBuckets bounds the clients remembered; idle ones are forgotten first.
+const maxBuckets = 10000
+
+func newLimits(maxSearches, maxQueued int, rate float64, burst int) *limits {
+	l := &limits{maxQueued: maxQueued, rate: rate, burst: math.Max(1, float64(burst)), buckets: map[string]*bucket{}}
+	if maxSearches > 0 {
+		l.slots = make(chan struct{}, maxSearches)
+	}
+	return l
+}
+
+// admit waits for a search by client to be allowed to run and returns the
+// func to call once it is done. Follow searches, which mostly wait for
+// new lines, are rate limited but do not take a slot. A search that never
+// runs, for lack of room in the queue or because its caller gave up while
+// queued, gives its rate token back.
+func (l *limits) admit(ctx context.Context, client string, follow bool) (done func(), err error) {
+	if wait := l.take(client); wait > 0 {
+		return nil, busy(wait, "%s is over %g searches per second", client, l.rate)
+	}
+	if l.slots == nil || follow {
+		return func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return l.release(time.Now()), nil
+	default:
+	}
+	l.mu.Lock()
+	if l.queued >= l.maxQueued {
+		avg := l.avg
+		l.refund(client)
+		l.mu.Unlock()
+		return nil, busy(avg, "%d searches running and %d waiting", cap(l.slots), l.maxQueued)
+	}
+	l.queued++
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.queued--
+		l.mu.Unlock()
+	}()
+	select {
+	case l.slots <- struct{}{}:
+		return l.release(time.Now()), nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.refund(client)
+		l.mu.Unlock()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// running is the number of searches holding a slot.
+func (l *limits) running() int {
+	return len(l.slots)
+}
+
+// waiting is the number of searches queued for a slot.
+func (l *limits) waiting() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.queued
+}
+
+func (l *limits) release(start time.Time) func() {
+	return func() {
+		d := time.Since(start)
+		l.mu.Lock()
+		if l.avg == 0 {
+			l.avg = d
+		} else {
+			l.avg = (7*l.avg + d) / 8
+		}
+		l.mu.Unlock()
+		<-l.slots
+	}
+}
+
+// take spends one of client's tokens, or returns how long until it has
+// one.
+func (l *limits) take(client string) time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.buckets[client]
+	if b == nil {
+		if len(l.buckets) >= maxBuckets {
+			l.forget(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+// refund gives back the token take spent for client. l.mu must be held.
+func (l *limits) refund(client string) {
+	if b := l.buckets[client]; b != nil {
+		b.tokens = math.Min(l.burst, b.tokens+1)
+	}
+}
+
+// forget drops the buckets that have refilled, which are no different
+// from new ones.
+func (l *limits) forget(now time.Time) {
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for c, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, c)
+		}
+	}
+}
+
+// clientOf names the caller a rate applies to: its identity if it has
+// one, otherwise its IP address, so one host's connections share a rate.
+func clientOf(ctx context.Context) string {
+	if id := auth.Identity(ctx); id != "" {
+		return id
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// minRetry keeps retry hints from inviting a client straight back.
+const minRetry = 100 * time.Millisecond
+
+// busy returns a ResourceExhausted error telling the client to retry
+// after wait.
+func busy(wait time.Duration, format string, args ...any) error {
+	wait = max(wait, minRetry)
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("worker busy: "+format+"; retry in %v", append(args, wait.Round(time.Millisecond))...))
+	if d, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+		st = d
+	}
+	return st.Err()
+}
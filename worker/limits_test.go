@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdmitRefundsRefusedSearches(t *testing.T) {
+	// One slot, no queue, and a rate so low that a spent token does not
+	// come back during the test.
+	l := newLimits(1, 0, 0.001, 1)
+	ctx := context.Background()
+	done, err := l.admit(ctx, "a", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if _, err := l.admit(ctx, "b", false); status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("admit with every slot taken = %v, want ResourceExhausted", err)
+		}
+	}
+	done()
+	// b was only ever refused for room, so it still has its token.
+	done, err = l.admit(ctx, "b", false)
+	if err != nil {
+		t.Fatalf("admit after a slot freed up: %v", err)
+	}
+	done()
+	if _, err := l.admit(ctx, "b", false); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("admit over the rate = %v, want ResourceExhausted", err)
+	}
+}
+
+func TestAdmitRefundsCanceledWaits(t *testing.T) {
+	l := newLimits(1, 1, 0.001, 1)
+	done, err := l.admit(context.Background(), "a", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.admit(ctx, "b", false); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("admit while queued = %v, want DeadlineExceeded", err)
+	}
+	done()
+	if done, err = l.admit(context.Background(), "b", false); err != nil {
+		t.Fatalf("admit after giving up in the queue: %v", err)
+	}
+	done()
+}
@@ -1,111 +1,844 @@
 package main
 
 import (
+	"MP1/audit"
+	"MP1/auth"
+	"MP1/client"
+	"MP1/membership"
+	"MP1/properties"
 	grep "MP1/protoBuilds"
-	"bufio"
+	"MP1/replica"
+	"MP1/search"
+	"MP1/tlsutil"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type server struct {
 	grep.UnimplementedGrepServiceServer
 	logDir     string
 	glob       string
+	rotated    bool
+	timeLayout *search.TimeLayout
 	workerHost string
+	shard      string               // this worker's name in cluster.properties, or its label
+	replicas   *replica.Store       // copies of peers' logs, searched on failover
+	members    *membership.Detector // nil unless started with -props
+	audit      *audit.Log           // nil unless started with -audit
+	limits     *limits
+	metrics    *metrics
+	followPoll time.Duration
 }
 
-func (s *server) Search(req *grep.SearchRequest, stream grep.GrepService_SearchServer) error {
-	fmt.Fprintf(os.Stderr, "[%s] scanning logdir=%s glob=%s\n", s.workerHost, s.logDir, s.glob)
-	files, _ := filepath.Glob(filepath.Join(s.logDir, s.glob))
+// search runs a Search, adding the files it reads to rec.
+func (s *server) search(req *grep.SearchRequest, stream grep.GrepService_SearchServer, rec *grep.AuditRecord) error {
+	opts, err := requestOptions(req)
+	if err == nil {
+		opts.Window = search.TimeRange{Layout: s.timeLayout}
+		if req.Since != nil {
+			opts.Window.Since = req.Since.AsTime()
+		}
+		if req.Until != nil {
+			opts.Window.Until = req.Until.AsTime()
+		}
+		err = opts.Validate()
+	}
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	sr, err := search.New(opts)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "pattern: %v", err)
+	}
+	if err := auth.Require(stream.Context(), searchMode(req.Mode, opts)); err != nil {
+		return err
+	}
+	var key func([]byte) (string, bool)
+	if req.Mode == "aggregate" {
+		g, err := search.FromAggregation(req.Aggregate)
+		if err == nil {
+			key, err = sr.Keyer(g)
+		}
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "aggregate: %v", err)
+		}
+	}
+	// Acknowledge the search before scanning so the coordinator's
+	// first-byte timeout measures responsiveness, not scan time.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	done, err := s.limits.admit(stream.Context(), clientOf(stream.Context()), req.Follow)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	src, err := s.source(req.Shard)
+	if err != nil {
+		return err
+	}
+	shard := src.shard
+	if req.Follow {
+		if req.Mode == "count" || req.Mode == "aggregate" || opts.Count {
+			return status.Errorf(codes.InvalidArgument, "follow needs lines mode")
+		}
+		return s.follow(stream, sr, src, req.MaxResults, rec)
+	}
+	fmt.Fprintf(os.Stderr, "[%s] scanning %s glob=%s\n", s.workerHost, src.dir, src.glob)
+	files, err := search.Files(src.dir, src.glob, src.rotated)
+	if err != nil {
+		return status.Errorf(codes.Internal, "glob: %v", err)
+	}
+	if len(files) == 0 && shard != s.shard {
+		return status.Errorf(codes.NotFound, "no replica of %s held here", shard)
+	}
+	files = auth.FromContext(stream.Context()).Filter(files)
+	if opts.Window.Active() {
+		kept := files[:0]
+		for _, fp := range files {
+			if opts.Window.SkipFile(fp) {
+				fmt.Fprintf(os.Stderr, "[%s] skipping %s: outside time range\n", s.workerHost, fp)
+				continue
+			}
+			kept = append(kept, fp)
+		}
+		files = kept
+	}
 	fmt.Fprintf(os.Stderr, "[%s] matched files: %v\n", s.workerHost, files)
+	rec.Files = files
 	if len(files) == 0 {
-		fmt.Fprintf(os.Stderr, "[%s] no files matched in %s glob %s\n", s.workerHost, s.logDir, s.glob)
+		fmt.Fprintf(os.Stderr, "[%s] no files matched for shard %s\n", s.workerHost, shard)
 		return nil
 	}
+	fmt.Fprintf(os.Stderr, "[%s] searching: mode=%s options=%+v\n", s.workerHost, req.Mode, opts)
 
+	ctx := stream.Context()
+	if req.Mode == "aggregate" {
+		return s.aggregate(ctx, stream, sr, key, files, shard, req.Aggregate.MaxGroups)
+	}
 	if req.Mode == "count" {
-		args := append([]string{"-H", "-c"}, req.GrepOptions...)
-		args = append(args, files...)
-		fmt.Fprintf(os.Stderr, "[%s] running: grep %v\n", s.workerHost, args)
-		cmd := exec.CommandContext(stream.Context().(context.Context), "grep", args...)
-		fmt.Fprintf(os.Stderr, "[%s] exec: %s\n", s.workerHost, cmd.String())
-		stdout, err := cmd.StdoutPipe()
+		resp := &grep.SearchResponse{Host: s.workerHost, Shard: shard}
+		for _, fp := range files {
+			n, err := s.scanFile(ctx, sr, fp, nil)
+			if err != nil {
+				return err
+			}
+			resp.Count += n
+			resp.FileCounts = append(resp.FileCounts, &grep.FileCount{FilePath: fp, Count: n})
+		}
+		fmt.Fprintf(os.Stderr, "[%s] sending count=%d\n", s.workerHost, resp.Count)
+		return stream.SendMsg(resp)
+	}
+
+	hk := newHunks(opts, new(atomic.Int64))
+	send := limit(req.MaxResults, stream.Send)
+	for _, fp := range files {
+		if opts.Count {
+			// grep -c in lines mode reports one count per file.
+			n, err := s.scanFile(ctx, sr, fp, nil)
+			if err != nil {
+				return err
+			}
+			if err := stream.SendMsg(&grep.SearchResponse{Host: s.workerHost, Shard: shard, FilePath: fp, Log: strconv.FormatInt(n, 10)}); err != nil {
+				return err
+			}
+			continue
+		}
+		_, err := s.scanFile(ctx, sr, fp, func(h search.Hit) error {
+			return send(s.lineResponse(shard, fp, h, hk.of(fp, h.Line)))
+		})
+		if err == errLimit {
+			s.logf("stopped after %d results", req.MaxResults)
+			return nil
+		}
 		if err != nil {
 			return err
 		}
-		if err := cmd.Start(); err != nil {
+	}
+	return nil
+}
+
+// searchMode is the ACL mode a search needs. grep -c in lines mode only
+// returns counts, so it needs count mode.
+func searchMode(mode string, opts search.Options) string {
+	switch {
+	case mode == "count" || mode == "aggregate":
+		return mode
+	case opts.Count:
+		return auth.ModeCount
+	}
+	return auth.ModeLines
+}
+
+// errLimit ends a search that has sent SearchRequest.maxResults lines.
+var errLimit = errors.New("result limit reached")
+
+// limit wraps send so that it sends at most maxResults selected lines, not
+// counting context lines, and then fails with errLimit. maxResults 0 means no
+// limit. The returned func is not safe for concurrent use.
+func limit(maxResults int64, send func(*grep.SearchResponse) error) func(*grep.SearchResponse) error {
+	if maxResults <= 0 {
+		return send
+	}
+	var n int64
+	return func(r *grep.SearchResponse) error {
+		if n >= maxResults {
+			return errLimit
+		}
+		if err := send(r); err != nil {
 			return err
 		}
-		defer cmd.Wait()
-		sum := int64(0)
-		sc := bufio.NewScanner(stdout)
-		for sc.Scan() {
-			line := sc.Text()
-			if i := strings.LastIndexByte(line, ':'); i >= 0 {
-				if n, err := strconv.Atoi(strings.TrimSpace(line[i+1:])); err == nil {
-					sum += int64(n)
-				}
+		if r.Kind == grep.LineKind_MATCH {
+			if n++; n >= maxResults {
+				return errLimit
 			}
 		}
-		fmt.Fprintf(os.Stderr, "[%s] sending count=%d\n", s.workerHost, sum)
-		return stream.SendMsg(&grep.SearchResponse{Host: s.workerHost, Count: sum})
+		return nil
 	}
+}
+
+// hunks numbers groups of adjacent output lines when context lines are
+// requested, so clients can separate them the way grep prints "--".
+type hunks struct {
+	on   bool
+	ids  *atomic.Int64 // shared by every hunks of one stream
+	id   int64
+	file string
+	last int64
+}
+
+func newHunks(opts search.Options, ids *atomic.Int64) *hunks {
+	return &hunks{on: !opts.OnlyMatching && (opts.BeforeContext > 0 || opts.AfterContext > 0), ids: ids}
+}
+
+// of returns the hunk of line in file fp; lines must come in order.
+func (h *hunks) of(fp string, line int64) int64 {
+	if !h.on {
+		return 0
+	}
+	if h.id == 0 || fp != h.file || line > h.last+1 {
+		h.id = h.ids.Add(1)
+	}
+	h.file, h.last = fp, line
+	return h.id
+}
+
+// lineResponse builds the response for one line of output.
+func (s *server) lineResponse(shard, fp string, h search.Hit, hunk int64) *grep.SearchResponse {
+	kind := grep.LineKind_MATCH
+	if h.Context {
+		kind = grep.LineKind_CONTEXT
+	}
+	return &grep.SearchResponse{Host: s.workerHost, Shard: shard, FilePath: fp, Log: h.Text, LineNumber: h.Line, ByteOffset: h.Offset, Kind: kind, Hunk: hunk}
+}
 
-	args := append([]string{"--line-buffered", "-H"}, req.GrepOptions...)
-	args = append(args, files...)
-	cmd := exec.CommandContext(stream.Context().(context.Context), "grep", args...)
-	fmt.Fprintf(os.Stderr, "[%s] exec: %s\n", s.workerHost, cmd.String())
-	stdout, err := cmd.StdoutPipe()
+// maxAround caps the context LinesAround returns on each side.
+const maxAround = 1000
+
+// LinesAround sends the line at an offset reported by Search and the lines
+// around it.
+func (s *server) LinesAround(req *grep.LinesAroundRequest, stream grep.GrepService_LinesAroundServer) error {
+	if req.ByteOffset < 0 || req.Before < 0 || req.After < 0 || req.Before > maxAround || req.After > maxAround {
+		return status.Errorf(codes.InvalidArgument, "byteOffset must not be negative, before and after must be 0..%d", maxAround)
+	}
+	if err := auth.Require(stream.Context(), auth.ModeLines); err != nil {
+		return err
+	}
+	src, err := s.source(req.Shard)
 	if err != nil {
 		return err
 	}
-	if err := cmd.Start(); err != nil {
+	fp, err := s.resolve(stream.Context(), src, req.FilePath)
+	if err != nil {
+		return err
+	}
+	f, _, err := search.Open(fp)
+	if err != nil {
+		return status.Errorf(codes.Internal, "open: %v", err)
+	}
+	defer f.Close()
+	err = search.LinesAround(stream.Context(), f, req.ByteOffset, int(req.Before), int(req.After), func(h search.Hit) error {
+		return stream.Send(s.lineResponse(src.shard, fp, h, 1))
+	})
+	if err == io.ErrUnexpectedEOF {
+		return status.Errorf(codes.OutOfRange, "offset %d is past the end of %s", req.ByteOffset, filepath.Base(fp))
+	}
+	return err
+}
+
+// readChunk is how much ReadRange sends per message.
+const readChunk = 64 * 1024
+
+// ReadRange streams lines or bytes of one of a shard's log files, so a
+// match can be looked at in place without logging in to the worker.
+func (s *server) ReadRange(req *grep.ReadRangeRequest, stream grep.GrepService_ReadRangeServer) error {
+	first := int64(0)
+	if req.Unit == grep.RangeUnit_LINES {
+		first = 1
+	}
+	if req.Start < first || (req.End != 0 && req.End < req.Start) {
+		return status.Errorf(codes.InvalidArgument, "bad range %d..%d", req.Start, req.End)
+	}
+	if err := auth.Require(stream.Context(), auth.ModeLines); err != nil {
 		return err
 	}
-	defer cmd.Wait()
-	sc := bufio.NewScanner(stdout)
-	buf := make([]byte, 0, 1024*1024)
-	sc.Buffer(buf, 1024*1024)
-	for sc.Scan() {
-		line := sc.Text()
-		fp := ""
-		if i := strings.IndexByte(line, ':'); i >= 0 {
-			fp = line[:i]
-			line = line[i+1:]
-		}
-		if err := stream.SendMsg(&grep.SearchResponse{Host: s.workerHost, FilePath: fp, Log: line}); err != nil {
+	src, err := s.source(req.Shard)
+	if err != nil {
+		return err
+	}
+	fp, err := s.resolve(stream.Context(), src, req.FilePath)
+	if err != nil {
+		return err
+	}
+	f, _, err := search.Open(fp)
+	if err != nil {
+		return status.Errorf(codes.Internal, "open: %v", err)
+	}
+	defer f.Close()
+	if req.Unit == grep.RangeUnit_BYTES {
+		err = readBytes(f, req.Start, req.End, stream)
+	} else {
+		err = readLines(stream.Context(), f, req.Start, req.End, stream)
+	}
+	if err == io.ErrUnexpectedEOF {
+		return status.Errorf(codes.OutOfRange, "%s %d is past the end of %s", strings.ToLower(strings.TrimSuffix(req.Unit.String(), "S")), req.Start, filepath.Base(fp))
+	}
+	return err
+}
+
+// readBytes sends bytes start up to end, or to the end of r when end is 0.
+func readBytes(r io.Reader, start, end int64, stream grep.GrepService_ReadRangeServer) error {
+	if _, err := io.CopyN(io.Discard, r, start); err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+	if end != 0 {
+		r = io.LimitReader(r, end-start)
+	}
+	buf := make([]byte, readChunk)
+	off := start
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if serr := stream.Send(&grep.FileChunk{Offset: off, Data: buf[:n]}); serr != nil {
+				return serr
+			}
+			off += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
 			return err
 		}
 	}
-	return nil
+}
+
+// readLines sends lines first through last, or to the end of r when last
+// is 0, batched into chunks of whole lines.
+func readLines(ctx context.Context, r io.Reader, first, last int64, stream grep.GrepService_ReadRangeServer) error {
+	var c *grep.FileChunk
+	flush := func() error {
+		if c == nil {
+			return nil
+		}
+		err := stream.Send(c)
+		c = nil
+		return err
+	}
+	err := search.Lines(ctx, r, first, last, func(h search.Hit) error {
+		if c == nil {
+			c = &grep.FileChunk{Offset: h.Offset, Line: h.Line}
+		}
+		c.Data = append(append(c.Data, h.Text...), '\n')
+		if len(c.Data) >= readChunk {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// ListFiles reports the files a search of the shard would read, so
+// "no matches" can be told apart from "no files". Any search mode allows
+// it, and only the files the caller may search are listed.
+func (s *server) ListFiles(ctx context.Context, req *grep.ListFilesRequest) (*grep.ListFilesResponse, error) {
+	if err := auth.RequireAny(ctx, auth.ModeLines, auth.ModeCount, auth.ModeAggregate); err != nil {
+		return nil, err
+	}
+	src, err := s.source(req.Shard)
+	if err != nil {
+		return nil, err
+	}
+	files, err := search.Files(src.dir, src.glob, src.rotated)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "glob: %v", err)
+	}
+	files = auth.FromContext(ctx).Filter(files)
+	resp := &grep.ListFilesResponse{Host: s.workerHost, Shard: src.shard, Dir: src.dir, Glob: src.glob, Rotated: src.rotated}
+	for _, fp := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		lf := &grep.LogFile{Path: fp}
+		if fi, err := os.Stat(fp); err == nil {
+			lf.Size, lf.ModTime = fi.Size(), timestamppb.New(fi.ModTime())
+		}
+		lines, exact, kind, err := search.EstimateLines(fp)
+		if err != nil {
+			lf.Error = err.Error()
+		}
+		lf.Lines, lf.LinesExact, lf.Compression = lines, exact, string(kind)
+		resp.Files = append(resp.Files, lf)
+	}
+	return resp, nil
+}
+
+// resolve maps a file name from a client, either a path returned by Search
+// or its base name, to one of the shard's log files. Only files a search
+// of the shard would read are accepted, so clients cannot read anything
+// else on the worker, and only those the caller's ACL entry allows.
+func (s *server) resolve(ctx context.Context, src logSource, name string) (string, error) {
+	files, err := search.Files(src.dir, src.glob, src.rotated)
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "glob: %v", err)
+	}
+	for _, fp := range files {
+		if name == fp || name == filepath.Base(fp) {
+			if !within(src.dir, fp) {
+				break
+			}
+			if u := auth.FromContext(ctx); !u.CanRead(fp) {
+				return "", status.Errorf(codes.PermissionDenied, "user %s may not read %s", u.Name, filepath.Base(fp))
+			}
+			return fp, nil
+		}
+	}
+	return "", status.Errorf(codes.NotFound, "%s is not a log file of %s", name, src.shard)
+}
+
+// within reports whether fp, with symlinks followed, is inside dir, so a
+// link in the log directory cannot expose files elsewhere.
+func within(dir, fp string) bool {
+	d, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	f, err := filepath.EvalSymlinks(fp)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(d, f)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// logSource is where a shard's log files are.
+type logSource struct {
+	shard     string
+	dir, glob string
+	rotated   bool
+}
+
+// source locates a shard's files: this worker's own logs when shard is
+// empty or names this worker, otherwise the replicas it holds for that
+// peer.
+func (s *server) source(shard string) (logSource, error) {
+	if shard == "" || shard == s.shard || shard == s.workerHost {
+		return logSource{shard: s.shard, dir: s.logDir, glob: s.glob, rotated: s.rotated}, nil
+	}
+	dir, err := s.replicas.ShardDir(shard)
+	if err != nil {
+		return logSource{}, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	glob, rotated, err := s.replicas.Source(shard)
+	if err != nil {
+		return logSource{}, status.Errorf(codes.Internal, "%v", err)
+	}
+	return logSource{shard: shard, dir: dir, glob: glob, rotated: rotated}, nil
+}
+
+func (s *server) Replicate(stream grep.GrepService_ReplicateServer) error {
+	if err := auth.Require(stream.Context(), auth.ModeCluster); err != nil {
+		return err
+	}
+	return s.replicas.Receive(stream)
+}
+
+func (s *server) Ping(ctx context.Context, req *grep.PingRequest) (*grep.PingAck, error) {
+	if err := auth.Require(ctx, auth.ModeCluster); err != nil {
+		return nil, err
+	}
+	if s.members == nil {
+		return nil, errNoMembership
+	}
+	return s.members.Ping(ctx, req)
+}
+
+func (s *server) PingReq(ctx context.Context, req *grep.PingReqRequest) (*grep.PingAck, error) {
+	if err := auth.Require(ctx, auth.ModeCluster); err != nil {
+		return nil, err
+	}
+	if s.members == nil {
+		return nil, errNoMembership
+	}
+	return s.members.PingReq(ctx, req)
+}
+
+func (s *server) Register(ctx context.Context, req *grep.RegisterRequest) (*grep.RegisterResponse, error) {
+	if err := auth.Require(ctx, auth.ModeCluster); err != nil {
+		return nil, err
+	}
+	if s.members == nil {
+		return nil, errNoMembership
+	}
+	return s.members.Register(ctx, req)
+}
+
+func (s *server) Deregister(ctx context.Context, req *grep.DeregisterRequest) (*grep.DeregisterResponse, error) {
+	if err := auth.Require(ctx, auth.ModeCluster); err != nil {
+		return nil, err
+	}
+	if s.members == nil {
+		return nil, errNoMembership
+	}
+	return s.members.Deregister(ctx, req)
+}
+
+func (s *server) Members(ctx context.Context, _ *grep.MembersRequest) (*grep.MembersResponse, error) {
+	// Coordinators read the membership list before every query, so any
+	// mode allows it.
+	if err := auth.RequireAny(ctx, auth.ModeLines, auth.ModeCount, auth.ModeAggregate, auth.ModeAudit, auth.ModeCluster); err != nil {
+		return nil, err
+	}
+	if s.members == nil {
+		return nil, errNoMembership
+	}
+	return &grep.MembersResponse{Members: s.members.Members()}, nil
+}
+
+var errNoMembership = status.Errorf(codes.FailedPrecondition, "membership is off; start the worker with -props")
+
+// requestOptions takes the search options from the typed query, or from the
+// legacy grepOptions field, which is only accepted through the allowlist in
+// search.ParseArgs.
+func requestOptions(req *grep.SearchRequest) (search.Options, error) {
+	if req.Query != nil {
+		if len(req.GrepOptions) > 0 {
+			return search.Options{}, fmt.Errorf("query and grepOptions are mutually exclusive")
+		}
+		return search.FromQuery(req.Query)
+	}
+	opts, err := search.ParseArgs(req.GrepOptions)
+	if err != nil {
+		return opts, fmt.Errorf("grep options: %v", err)
+	}
+	return opts, nil
+}
+
+// scanFile runs sr over one file, decompressing it if needed. Unreadable
+// files are reported and skipped, as grep does, so one bad file does not
+// fail the whole search.
+func (s *server) scanFile(ctx context.Context, sr *search.Searcher, fp string, fn func(search.Hit) error) (int64, error) {
+	f, kind, err := search.Open(fp)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] open %s: %v\n", s.workerHost, fp, err)
+		return 0, nil
+	}
+	defer f.Close()
+	var sendErr error
+	var emit func(search.Hit) error
+	if fn != nil {
+		emit = func(h search.Hit) error {
+			sendErr = fn(h)
+			return sendErr
+		}
+	}
+	n, err := sr.Scan(ctx, s.metrics.scanned(f), emit)
+	s.metrics.linesMatched.Add(float64(n))
+	if err != nil && sendErr == nil && ctx.Err() == nil && kind != search.Plain {
+		// A truncated or corrupt archive should not hide the other files.
+		fmt.Fprintf(os.Stderr, "[%s] read %s (%s): %v\n", s.workerHost, fp, kind, err)
+		return n, nil
+	}
+	return n, err
+}
+
+// clusterPlace loads cluster.properties and finds this worker in it. The
+// index is -1 for a worker that is not one of the seeds listed there.
+func clusterPlace(propsPath string, index int, label string) (properties.Props, client.Config, int, error) {
+	p, err := properties.Load(propsPath)
+	if err != nil {
+		return nil, client.Config{}, 0, err
+	}
+	cfg, err := client.ConfigFromProps(p)
+	if err != nil {
+		return nil, client.Config{}, 0, err
+	}
+	if index >= len(cfg.Targets) {
+		return nil, client.Config{}, 0, fmt.Errorf("-index %d: %s lists %d workers", index, propsPath, len(cfg.Targets))
+	}
+	if index < 0 {
+		for i, t := range cfg.Targets {
+			if t.Label == label {
+				index = i
+			}
+		}
+	}
+	return p, cfg, index, nil
+}
+
+// newPusher returns a pusher for the peers that should hold this worker's
+// replicas, or nil when the replication factor is 1.
+func newPusher(cfg client.Config, index int, srv *server) *replica.Pusher {
+	var peers []string
+	for _, j := range cfg.Successors(index) {
+		peers = append(peers, cfg.Targets[j].Addr)
+	}
+	if len(peers) == 0 {
+		return nil
+	}
+	return &replica.Pusher{
+		Shard:   cfg.Targets[index].Label,
+		Peers:   peers,
+		LogDir:  srv.logDir,
+		Glob:    srv.glob,
+		Rotated: srv.rotated,
+		Logf:    srv.logf,
+
+		DialOptions: cfg.DialOptions,
+	}
+}
+
+// newDetector returns a failure detector seeded with every worker in cfg.
+func newDetector(p properties.Props, cfg client.Config, self membership.Peer, srv *server) *membership.Detector {
+	mc := membership.ConfigFromProps(p)
+	mc.Self = self
+	mc.Globs = []string{srv.glob}
+	for _, t := range cfg.Targets {
+		mc.Seeds = append(mc.Seeds, membership.Peer{Label: t.Label, Addr: t.Addr})
+	}
+	mc.Logf = srv.logf
+	mc.DialOptions = cfg.DialOptions
+	return membership.New(mc)
+}
+
+// advertiseAddr is the address other workers and coordinators should use
+// to reach a worker listening on listenAddr.
+func advertiseAddr(advertise, listenAddr string) (string, error) {
+	if advertise != "" {
+		return advertise, nil
+	}
+	host, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return "", err
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		if host, err = os.Hostname(); err != nil {
+			return "", err
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+func (s *server) logf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "[%s] %s\n", s.workerHost, fmt.Sprintf(format, args...))
 }
 
 func main() {
 	address := flag.String("addr", ":6000", "Listening port")
 	logDir := flag.String("logdir", ".", "directory with logs")
 	glob := flag.String("glob", "machine.*.log", "glob for log files")
+	rotated := flag.Bool("rotated", true, "also search rotated and compressed copies (glob+\".*\")")
+	timeFmt := flag.String("timefmt", "apache", "log timestamp layout: syslog, rfc3339, apache, or a Go time layout")
 	workerHost := flag.String("label", "", "worker host")
+	propsPath := flag.String("props", "", "cluster.properties, whose workers are the seeds to register with; also enables the failure detector and replication")
+	advertise := flag.String("advertise", "", "address to register with, for a worker not listed in -props (default: hostname and the -addr port)")
+	index := flag.Int("index", -1, "this worker's N in peer.machine.*N (default: match -label against peer.machine.nameN)")
+	replicaDir := flag.String("replicadir", "", "directory for peers' replica logs (default <logdir>/.replicas)")
+	replicateEvery := flag.Duration("replicate-interval", 30*time.Second, "how often new log data is pushed to replica holders")
+	followPoll := flag.Duration("follow-poll", 500*time.Millisecond, "how often followed files are checked for new lines")
+	flagTLS := tlsutil.Files{}
+	flag.StringVar(&flagTLS.Cert, "tls-cert", "", "PEM certificate to serve and dial peers with (default tls.cert in -props)")
+	flag.StringVar(&flagTLS.Key, "tls-key", "", "PEM key for -tls-cert (default tls.key in -props)")
+	flag.StringVar(&flagTLS.CA, "tls-ca", "", "PEM CA that clients and peers must have certificates from (default tls.ca in -props)")
+	aclPath := flag.String("acl", "", "ACL file of the users allowed to call this worker; turns on authentication (default auth.acl in -props)")
+	auditPath := flag.String("audit", "", "file to append a JSON record of every search to (default audit.file in -props)")
+	auditMax := flag.Int64("audit-max-size", 64, "size in MiB at which the audit file is rotated")
+	auditKeep := flag.Int("audit-keep", 5, "rotated audit files kept")
+	maxSearches := flag.Int("max-searches", runtime.NumCPU(), "searches run at once, not counting follows; 0 for no limit")
+	maxQueued := flag.Int("max-queued", 32, "searches that may wait for one of -max-searches; more are refused as busy")
+	rate := flag.Float64("rate", 0, "searches per second each client may start, by identity or IP; 0 for no limit")
+	burst := flag.Int("burst", 10, "searches a client may start at once above -rate")
+	metricsAddr := flag.String("metrics-addr", "", "address to serve Prometheus metrics on at /metrics, such as :9100; empty for none")
+	tokenFile := flag.String("token-file", "", "file with the bearer token to call peers with (default auth.token.file in -props)")
 	flag.Parse()
 
+	layout, err := search.ParseTimeLayout(*timeFmt)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	listener, err := net.Listen("tcp", *address)
 	if err != nil {
 		fmt.Println("Failed to listen: ", err)
 		os.Exit(1)
 	}
 
-	s := grpc.NewServer()
-	grep.RegisterGrepServiceServer(s, &server{logDir: *logDir, glob: *glob, workerHost: *workerHost})
+	if *replicaDir == "" {
+		*replicaDir = filepath.Join(*logDir, ".replicas")
+	}
+	srv := &server{logDir: *logDir, glob: *glob, rotated: *rotated, timeLayout: layout, workerHost: *workerHost,
+		shard: *workerHost, replicas: &replica.Store{Dir: *replicaDir}, followPoll: *followPoll,
+		limits: newLimits(*maxSearches, *maxQueued, *rate, *burst)}
+	srv.metrics = newMetrics(srv.limits)
+	if *metricsAddr != "" {
+		ml, err := net.Listen("tcp", *metricsAddr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "metrics:", err)
+			os.Exit(1)
+		}
+		go func() {
+			fmt.Fprintln(os.Stderr, "metrics:", srv.metrics.serve(ml))
+		}()
+		fmt.Fprintf(os.Stderr, "[%s] serving metrics on http://%s/metrics\n", *workerHost, ml.Addr())
+	}
+	tf := flagTLS
+	if *propsPath != "" {
+		p, cfg, i, err := clusterPlace(*propsPath, *index, *workerHost)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		tf = tlsutil.FromProps(p).Override(flagTLS)
+		if tf.Enabled() {
+			opt, err := tf.DialOption()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			cfg.DialOptions = append(cfg.DialOptions, opt)
+		}
+		if *aclPath == "" {
+			*aclPath = p["auth.acl"]
+		}
+		if *tokenFile == "" {
+			*tokenFile = p["auth.token.file"]
+		}
+		if *auditPath == "" {
+			*auditPath = p["audit.file"]
+		}
+		if *tokenFile != "" {
+			token, err := auth.ReadToken(*tokenFile)
+			if err == nil && !tf.Enabled() {
+				err = fmt.Errorf("%s: tokens are only sent over TLS; configure tls.* too", *tokenFile)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			cfg.DialOptions = append(cfg.DialOptions, auth.DialOption(token))
+		}
+		var self membership.Peer
+		if i >= 0 {
+			self = membership.Peer{Label: cfg.Targets[i].Label, Addr: cfg.Targets[i].Addr}
+			srv.shard = self.Label
+			if pusher := newPusher(cfg, i, srv); pusher != nil {
+				pusher.Interval = *replicateEvery
+				fmt.Fprintf(os.Stderr, "[%s] replicating shard %s to %v\n", *workerHost, pusher.Shard, pusher.Peers)
+				go pusher.Run(context.Background())
+			}
+		} else {
+			// Not a seed: join through the seeds. Replicas follow the
+			// seed ring, so this worker's logs are not replicated.
+			if *workerHost == "" {
+				fmt.Fprintf(os.Stderr, "%s does not list this worker; pass -label to join\n", *propsPath)
+				os.Exit(1)
+			}
+			addr, err := advertiseAddr(*advertise, listener.Addr().String())
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			self = membership.Peer{Label: *workerHost, Addr: addr}
+			fmt.Fprintf(os.Stderr, "[%s] not a seed in %s; registering as %s\n", *workerHost, *propsPath, addr)
+		}
+		srv.members = newDetector(p, cfg, self, srv)
+		go srv.members.Run(context.Background())
+	}
+
+	if *auditPath != "" {
+		srv.audit = &audit.Log{Path: *auditPath, MaxSize: *auditMax << 20, Keep: *auditKeep}
+		if err := srv.audit.Open(); err != nil {
+			fmt.Fprintln(os.Stderr, "audit:", err)
+			os.Exit(1)
+		}
+		defer srv.audit.Close()
+		fmt.Fprintf(os.Stderr, "[%s] auditing searches to %s\n", *workerHost, *auditPath)
+	}
+
+	serverOpts := srv.metrics.serverOptions()
+	if *aclPath != "" {
+		acl, err := auth.Load(*aclPath)
+		if err == nil && !tf.Enabled() {
+			err = fmt.Errorf("%s: an ACL needs TLS, or tokens would cross the network in the clear", *aclPath)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		serverOpts = append(serverOpts, acl.ServerOptions()...)
+		fmt.Fprintf(os.Stderr, "[%s] authenticating callers against %s\n", *workerHost, *aclPath)
+	}
+	if tf.Enabled() {
+		opt, err := tf.ServerOption(*aclPath != "")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		serverOpts = append(serverOpts, opt)
+		fmt.Fprintf(os.Stderr, "[%s] serving TLS, client certificates required: %v\n", *workerHost, tf.CA != "" && *aclPath == "")
+	}
+	s := grpc.NewServer(serverOpts...)
+	grep.RegisterGrepServiceServer(s, srv)
+	// Deregister on the way out so the cluster does not have to detect
+	// the departure as a failure.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		if srv.members != nil {
+			srv.members.Leave(context.Background())
+			fmt.Fprintf(os.Stderr, "[%s] deregistered\n", *workerHost)
+		}
+		s.Stop()
+	}()
 	fmt.Println("Worker is listening on", *address)
 	if err := s.Serve(listener); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to serve", err)
+		fmt.Fprintln(os.Stderr, "Failed to serve:", err)
 	}
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+)
+
+// metrics are what a worker exposes on -metrics-addr for Prometheus.
+type metrics struct {
+	reg *prometheus.Registry
+
+	searches       *prometheus.CounterVec   // by mode and status code
+	searchDuration *prometheus.HistogramVec // by mode
+	bytesScanned   prometheus.Counter
+	linesMatched   prometheus.Counter
+	linesSent      prometheus.Counter
+	rpcs           *prometheus.CounterVec // by method and status code
+	activeStreams  *prometheus.GaugeVec   // by method
+}
+
+func newMetrics(l *limits) *metrics {
+	m := &metrics{
+		reg: prometheus.NewRegistry(),
+		searches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dgrep_worker_searches_total",
+			Help: "Searches served, by mode and the gRPC status code they ended with.",
+		}, []string{"mode", "code"}),
+		searchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "dgrep_worker_search_duration_seconds",
+			Help:    "Time from accepting a search to its end, queueing included, by mode.",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 14),
+		}, []string{"mode"}),
+		bytesScanned: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dgrep_worker_bytes_scanned_total",
+			Help: "Bytes of log read by searches, after decompression.",
+		}),
+		linesMatched: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dgrep_worker_lines_matched_total",
+			Help: "Lines selected by searches, in every mode.",
+		}),
+		linesSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dgrep_worker_lines_sent_total",
+			Help: "File lines sent to clients, context lines included.",
+		}),
+		rpcs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dgrep_worker_rpcs_total",
+			Help: "Finished RPCs, by method and gRPC status code.",
+		}, []string{"method", "code"}),
+		activeStreams: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dgrep_worker_active_streams",
+			Help: "Streaming RPCs in progress, by method.",
+		}, []string{"method"}),
+	}
+	m.reg.MustRegister(m.searches, m.searchDuration, m.bytesScanned, m.linesMatched, m.linesSent, m.rpcs, m.activeStreams,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "dgrep_worker_searches_running",
+			Help: "Searches holding one of -max-searches.",
+		}, func() float64 { return float64(l.running()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "dgrep_worker_searches_queued",
+			Help: "Searches waiting for one of -max-searches.",
+		}, func() float64 { return float64(l.waiting()) }),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// searchLabel is the mode label of a search. Modes come from clients, so
+// anything unknown is lumped with lines rather than growing the label set.
+func searchLabel(mode string, follow bool) string {
+	switch {
+	case follow:
+		return "follow"
+	case mode == "count" || mode == "aggregate":
+		return mode
+	}
+	return "lines"
+}
+
+// observe records a finished search.
+func (m *metrics) observe(mode string, code string, d time.Duration) {
+	m.searches.WithLabelValues(mode, code).Inc()
+	m.searchDuration.WithLabelValues(mode).Observe(d.Seconds())
+}
+
+// scanned wraps r so the bytes read from it count as scanned.
+func (m *metrics) scanned(r io.Reader) io.Reader {
+	return &countedReader{r: r, c: m.bytesScanned}
+}
+
+type countedReader struct {
+	r io.Reader
+	c prometheus.Counter
+}
+
+func (c *countedReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.c.Add(float64(n))
+	return n, err
+}
+
+// serverOptions returns interceptors counting every RPC and the streams
+// in progress. They come first so that RPCs refused by later ones, such
+// as for authentication, are counted too.
+func (m *metrics) serverOptions() []grpc.ServerOption {
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		m.rpcs.WithLabelValues(path.Base(info.FullMethod), codeOf(err).String()).Inc()
+		return resp, err
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		method := path.Base(info.FullMethod)
+		m.activeStreams.WithLabelValues(method).Inc()
+		defer m.activeStreams.WithLabelValues(method).Dec()
+		err := handler(srv, ss)
+		m.rpcs.WithLabelValues(method, codeOf(err).String()).Inc()
+		return err
+	}
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(unary), grpc.ChainStreamInterceptor(stream)}
+}
+
+// serve exposes the metrics at /metrics on l until the process exits.
+func (m *metrics) serve(l net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{}))
+	return http.Serve(l, mux)
+}